@@ -0,0 +1,143 @@
+package main
+
+import (
+	"cf-cli/cloudflare"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/urfave/cli/v2"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+var APIFlags = []cli.Flag{
+	&cli.StringSliceFlag{
+		Name:    "field",
+		Aliases: []string{"F"},
+		Usage:   "Parameter key=value, sent in the query of GET, HEAD and DELETE requests and in the JSON body otherwise. Values that are valid JSON are sent as such, Eg. ttl=300 or tags='[\"a\"]'",
+	},
+	&cli.StringSliceFlag{
+		Name:    "raw-field",
+		Aliases: []string{"f"},
+		Usage:   "Like --field, but the value is always sent as a string",
+	},
+	&cli.StringFlag{
+		Name:  "input",
+		Usage: "File to send as the JSON body, - for stdin. Fields are then sent in the query",
+	},
+}
+
+type apiField struct {
+	Key   string
+	Raw   string
+	Value any
+}
+
+func apiFields(c *cli.Context) ([]apiField, error) {
+	var fields []apiField
+	for _, name := range []string{"field", "raw-field"} {
+		for _, field := range c.StringSlice(name) {
+			key, raw, ok := strings.Cut(field, "=")
+			if !ok || key == "" {
+				return nil, fmt.Errorf("invalid --%s '%s', expected key=value", name, field)
+			}
+			var value any = raw
+			if name == "field" && json.Valid([]byte(raw)) {
+				json.Unmarshal([]byte(raw), &value)
+			}
+			fields = append(fields, apiField{Key: key, Raw: raw, Value: value})
+		}
+	}
+	return fields, nil
+}
+
+// API sends any request to the API with the credentials of the profile and
+// prints its result, Eg. 'cf-cli api GET /zones/{zone_id}/dns_settings'.
+func (h *Handler) API(c *cli.Context) error {
+	if err := h.shouldReady(); err != nil {
+		return err
+	}
+	args, err := interspersedArgs(c)
+	if err != nil {
+		return err
+	}
+	if len(args) != 2 {
+		return &UsageError{Err: errors.New("usage: cf-cli api METHOD PATH [OPTIONS]")}
+	}
+	method, path := strings.ToUpper(args[0]), args[1]
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	output, err := NewOutput(c)
+	if err != nil {
+		return err
+	}
+
+	fields, err := apiFields(c)
+	if err != nil {
+		return err
+	}
+
+	var opts []cloudflare.RequestOption
+	inQuery := c.IsSet("input")
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodDelete:
+		inQuery = true
+	}
+	if inQuery {
+		query := make(map[string]string, len(fields))
+		for _, field := range fields {
+			query[field.Key] = field.Raw
+		}
+		opts = append(opts, cloudflare.UseQueryParametersWithMap(query))
+	} else if len(fields) > 0 {
+		body := make(map[string]any, len(fields))
+		for _, field := range fields {
+			body[field.Key] = field.Value
+		}
+		opts = append(opts, cloudflare.UseJSONBody(body))
+	}
+	if name := c.String("input"); name != "" {
+		raw, err := readInput(name)
+		if err != nil {
+			return errors.New("failed to read input, cause: " + err.Error())
+		}
+		if !json.Valid(raw) {
+			return errors.New("the input is not valid JSON")
+		}
+		opts = append(opts, cloudflare.UseJSONBody(json.RawMessage(raw)))
+	}
+
+	if !strings.Contains(path, "{zone_id}") {
+		return h.request(c, output, method, path, opts...)
+	}
+	return h.inZone(c, func(zoneID string) error {
+		return h.request(c, output, method, strings.ReplaceAll(path, "{zone_id}", zoneID), opts...)
+	})
+}
+
+// request sends the request of API and prints its result.
+func (h *Handler) request(c *cli.Context, output *Output, method, path string, opts ...cloudflare.RequestOption) error {
+	var result any
+	response, err := h.Client.Do(c.Context, method, path, &result, opts...)
+	if err != nil {
+		return err
+	}
+
+	list, ok := result.([]any)
+	if !ok {
+		return output.Value(result)
+	}
+	var columns []string
+	if len(list) > 0 {
+		if first, ok := list[0].(map[string]any); ok {
+			for key := range first {
+				columns = append(columns, key)
+			}
+			sort.Strings(columns)
+		}
+	}
+	return output.List(list, response.ResultInfo, columns...)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAPIFieldsAfterPath(t *testing.T) {
+	isolate(t)
+	s := newStandIn(t, nil)
+
+	if _, err := s.run(t, nil, "api", "GET", "/zones/{zone_id}/dns_settings", "--zone-id", "abc", "--field", "per_page=2", "-f", "name=007"); err != nil {
+		t.Fatalf("failed to run api GET: %s", err)
+	}
+	if _, err := s.run(t, nil, "api", "post", "zones/abc/dns_records", "-F", "ttl=300", "--raw-field", "name=007", "-F", `tags=["a"]`); err != nil {
+		t.Fatalf("failed to run api POST: %s", err)
+	}
+
+	requests := s.Requests()
+	get, post := requests[0], requests[1]
+	if get.Method != "GET" || get.Path != "/client/v4/zones/abc/dns_settings" {
+		t.Errorf("GET request = %s %s", get.Method, get.Path)
+	}
+	if get.Query.Get("per_page") != "2" || get.Query.Get("name") != "007" || len(get.Raw) != 0 {
+		t.Errorf("GET fields = %v, body %q, want them in the query", get.Query, get.Raw)
+	}
+	if post.Method != "POST" || post.Path != "/client/v4/zones/abc/dns_records" || len(post.Query) != 0 {
+		t.Errorf("POST request = %s %s?%v", post.Method, post.Path, post.Query)
+	}
+	if post.Body["ttl"] != float64(300) || post.Body["name"] != "007" {
+		t.Errorf("POST body = %v, want typed ttl and raw name", post.Body)
+	}
+	if tags, ok := post.Body["tags"].([]any); !ok || len(tags) != 1 || tags[0] != "a" {
+		t.Errorf("POST tags = %v, want [a]", post.Body["tags"])
+	}
+}
+
+func TestAPIInputFromStdin(t *testing.T) {
+	isolate(t)
+	s := newStandIn(t, nil)
+
+	stdinFrom(t, `{"type":"A","name":"www","content":"192.0.2.1"}`)
+
+	if _, err := s.run(t, nil, "api", "POST", "/zones/abc/dns_records", "--input", "-", "-F", "x=1"); err != nil {
+		t.Fatalf("failed to run api: %s", err)
+	}
+	request := s.Requests()[0]
+	if request.Body["content"] != "192.0.2.1" || len(request.Body) != 3 {
+		t.Errorf("body = %v, want the input", request.Body)
+	}
+	if request.Query.Get("x") != "1" {
+		t.Errorf("query = %v, want the fields next to --input", request.Query)
+	}
+}
+
+func TestAPIUsage(t *testing.T) {
+	isolate(t)
+	s := newStandIn(t, nil)
+	if _, err := s.run(t, nil, "api", "GET"); err == nil {
+		t.Errorf("api with a single argument succeeded")
+	}
+	if _, err := s.run(t, nil, "api", "GET", "/zones", "--unknown"); err == nil {
+		t.Errorf("api with an unknown flag succeeded")
+	}
+	if len(s.Requests()) != 0 {
+		t.Errorf("invalid invocations sent requests")
+	}
+}
+
+func TestAPIHead(t *testing.T) {
+	isolate(t)
+	s := newStandIn(t, nil)
+
+	stdout, err := s.run(t, nil, "api", "HEAD", "/zones/abc", "-F", "name=example.com")
+	if err != nil {
+		t.Fatalf("failed to run api HEAD: %s", err)
+	}
+	if !strings.Contains(stdout, `"success":true`) {
+		t.Errorf("printed %q", stdout)
+	}
+	if r := s.Requests()[0]; r.Method != "HEAD" || r.Query.Get("name") != "example.com" {
+		t.Errorf("requested %s %s?%v", r.Method, r.Path, r.Query)
+	}
+}
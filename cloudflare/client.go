@@ -0,0 +1,315 @@
+// Package cloudflare is a small client for the Cloudflare DNS Records API.
+package cloudflare
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+const DefaultBaseURL = "https://api.cloudflare.com/client/v4"
+
+type Credentials struct {
+	XAuthEmail string `json:"x_auth_email,omitempty"`
+	XAuthKey   string `json:"x_auth_key,omitempty"`
+	APIToken   string `json:"api_token,omitempty"`
+}
+
+type ResponseInfo struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type ResultInfo struct {
+	Count      int `json:"count"`
+	Page       int `json:"page"`
+	PerPage    int `json:"per_page"`
+	TotalCount int `json:"total_count"`
+	TotalPages int `json:"total_pages"`
+}
+
+type Response struct {
+	Success    bool            `json:"success"`
+	Errors     []ResponseInfo  `json:"errors"`
+	Messages   []ResponseInfo  `json:"messages"`
+	Result     json.RawMessage `json:"result"`
+	ResultInfo *ResultInfo     `json:"result_info,omitempty"`
+}
+
+// ErrNotFound is wrapped by errors for lookups that matched nothing.
+var ErrNotFound = errors.New("not found")
+
+// Error is returned when the API answers with success set to false.
+type Error struct {
+	StatusCode int
+	Errors     []ResponseInfo
+}
+
+func (e *Error) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, info := range e.Errors {
+		messages = append(messages, fmt.Sprintf("%d: %s", info.Code, info.Message))
+	}
+	if len(messages) == 0 {
+		return fmt.Sprintf("request failed with status %d", e.StatusCode)
+	}
+	return strings.Join(messages, "; ")
+}
+
+// IsAuth reports whether the credentials were missing, invalid or lacked the
+// required permissions.
+func (e *Error) IsAuth() bool {
+	return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden ||
+		e.hasCode(6003, 6103, 6111, 9103, 9106, 9109, 10000)
+}
+
+func (e *Error) IsNotFound() bool {
+	return e.StatusCode == http.StatusNotFound || e.hasCode(7000, 7003, 81044)
+}
+
+func (e *Error) IsRateLimited() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.hasCode(971, 10429)
+}
+
+func (e *Error) IsValidation() bool {
+	return e.StatusCode == http.StatusBadRequest || e.StatusCode == http.StatusUnprocessableEntity
+}
+
+func (e *Error) hasCode(codes ...int) bool {
+	for _, info := range e.Errors {
+		for _, code := range codes {
+			if info.Code == code {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// NetworkError is returned when no response was received from the API.
+type NetworkError struct {
+	Err error
+}
+
+func (e *NetworkError) Error() string {
+	return "failed to request, cause: " + e.Err.Error()
+}
+
+func (e *NetworkError) Unwrap() error {
+	return e.Err
+}
+
+type Client struct {
+	BaseURL     string
+	HTTPClient  *http.Client
+	Credentials Credentials
+	Retry       RetryPolicy
+	Limiter     *RateLimiter
+
+	// Logf reports retried attempts when it is not nil.
+	Logf func(format string, args ...any)
+}
+
+func NewClient(credentials Credentials) *Client {
+	return &Client{
+		BaseURL:     DefaultBaseURL,
+		HTTPClient:  http.DefaultClient,
+		Credentials: credentials,
+		Retry:       DefaultRetryPolicy,
+		Limiter:     NewRateLimiter(DefaultRateLimit),
+	}
+}
+
+type RequestOption func(r *http.Request)
+
+func UsePathParameters(name, value string) RequestOption {
+	return func(r *http.Request) {
+		r.URL.Path = strings.ReplaceAll(r.URL.Path, fmt.Sprintf("{%s}", name), value)
+	}
+}
+
+func UseQueryParameters(key, value string) RequestOption {
+	return func(r *http.Request) {
+		if key == "" || value == "" {
+			return
+		}
+		query := r.URL.Query()
+		query.Set(key, value)
+		r.URL.RawQuery = query.Encode()
+	}
+}
+
+func UseQueryParametersWithMap(sets map[string]string) RequestOption {
+	return func(r *http.Request) {
+		query := r.URL.Query()
+		for key, value := range sets {
+			if key == "" || value == "" {
+				continue
+			}
+			query.Set(key, value)
+		}
+		r.URL.RawQuery = query.Encode()
+	}
+}
+
+func UseSecurity(c Credentials) RequestOption {
+	return func(r *http.Request) {
+		if c.XAuthEmail != "" && c.XAuthKey != "" {
+			r.Header.Set("X-Auth-Email", c.XAuthEmail)
+			r.Header.Set("X-Auth-Key", c.XAuthKey)
+		}
+
+		if c.APIToken != "" {
+			r.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.APIToken))
+		}
+	}
+}
+
+func UseBody(body io.Reader) RequestOption {
+	return func(r *http.Request) {
+		rc, ok := body.(io.ReadCloser)
+		if !ok {
+			rc = io.NopCloser(body)
+		}
+		r.Body = rc
+	}
+}
+
+func UseJSONBody(v any) RequestOption {
+	buf := &bytes.Buffer{}
+	json.NewEncoder(buf).Encode(v)
+	return useRawBody("application/json", buf.Bytes())
+}
+
+// UseMultipartBody sends fields and a single file, named fileName in the form
+// field fileField, as multipart/form-data.
+func UseMultipartBody(fields map[string]string, fileField, fileName string, file []byte) RequestOption {
+	buf := &bytes.Buffer{}
+	writer := multipart.NewWriter(buf)
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		writer.WriteField(key, fields[key])
+	}
+	part, _ := writer.CreateFormFile(fileField, fileName)
+	part.Write(file)
+	writer.Close()
+	return useRawBody(writer.FormDataContentType(), buf.Bytes())
+}
+
+// useRawBody sends raw in a way that can be read again when retrying.
+func useRawBody(contentType string, raw []byte) RequestOption {
+	return func(r *http.Request) {
+		r.Header.Set("Content-Type", contentType)
+		r.ContentLength = int64(len(raw))
+		r.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(raw)), nil
+		}
+		r.Body, _ = r.GetBody()
+	}
+}
+
+// send passes request through the rate limiter to the HTTP client.
+func (c *Client) send(ctx context.Context, request *http.Request) (*http.Response, error) {
+	if err := c.Limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	response, err := c.HTTPClient.Do(request)
+	if err == nil {
+		c.Limiter.Observe(response)
+	}
+	return response, err
+}
+
+// Do sends a request to api, which is relative to BaseURL, and decodes the
+// result of the response envelope into result when it is not nil. Plain text
+// responses are delivered as a JSON string result, empty successful responses
+// as no result. Requests answered with 429 or a 5xx status are retried
+// according to Retry.
+func (c *Client) Do(ctx context.Context, method, api string, result any, opts ...RequestOption) (*Response, error) {
+	request, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(c.BaseURL, "/")+api, nil)
+	if err != nil {
+		return nil, errors.New("failed to NewRequest, cause: " + err.Error())
+	}
+
+	UseSecurity(c.Credentials)(request)
+	for _, opt := range opts {
+		opt(request)
+	}
+
+	response, err := c.send(ctx, request)
+	for retries := 0; err == nil; retries++ {
+		wait, ok := c.Retry.wait(request, response, retries)
+		if !ok {
+			break
+		}
+		io.Copy(io.Discard, response.Body)
+		response.Body.Close()
+		if c.Logf != nil {
+			c.Logf("%s %s: status %d, retry %d of %d in %s", method, request.URL.Path, response.StatusCode, retries+1, c.Retry.MaxRetries, wait.Round(time.Millisecond))
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, &NetworkError{Err: ctx.Err()}
+		case <-timer.C:
+		}
+
+		request = request.Clone(ctx)
+		if request.GetBody != nil {
+			if request.Body, err = request.GetBody(); err != nil {
+				break
+			}
+		}
+		response, err = c.send(ctx, request)
+	}
+	if err != nil {
+		return nil, &NetworkError{Err: err}
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, errors.New("failed to read response body, cause: " + err.Error())
+	}
+
+	// HEAD requests and 204 No Content carry no envelope.
+	if len(bytes.TrimSpace(body)) == 0 && response.StatusCode < http.StatusBadRequest {
+		return &Response{Success: true}, nil
+	}
+
+	envelope := &Response{}
+	if strings.HasPrefix(response.Header.Get("Content-Type"), "text/plain") {
+		raw, _ := json.Marshal(string(body))
+		envelope.Success = response.StatusCode < http.StatusBadRequest
+		envelope.Result = raw
+	} else if err = json.Unmarshal(body, envelope); err != nil {
+		if response.StatusCode >= http.StatusBadRequest {
+			return nil, &Error{StatusCode: response.StatusCode}
+		}
+		return nil, fmt.Errorf("failed to parse response with status %d, cause: %s", response.StatusCode, err)
+	}
+
+	if !envelope.Success {
+		return envelope, &Error{StatusCode: response.StatusCode, Errors: envelope.Errors}
+	}
+	if result != nil && len(envelope.Result) > 0 {
+		if err = json.Unmarshal(envelope.Result, result); err != nil {
+			return envelope, errors.New("failed to parse result, cause: " + err.Error())
+		}
+	}
+	return envelope, nil
+}
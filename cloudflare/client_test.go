@@ -0,0 +1,140 @@
+package cloudflare
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newTestClient returns a client of a server answering with handler, without
+// retries or rate limiting.
+func newTestClient(t *testing.T, handler http.HandlerFunc) (*Client, *httptest.Server) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	client := NewClient(Credentials{APIToken: "token"})
+	client.BaseURL = server.URL + "/client/v4"
+	client.HTTPClient = server.Client()
+	client.Retry = RetryPolicy{}
+	client.Limiter = nil
+	return client, server
+}
+
+func writeJSON(w http.ResponseWriter, status int, body string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write([]byte(body))
+}
+
+func TestDoDecodesEnvelope(t *testing.T) {
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+		}
+		writeJSON(w, http.StatusOK, `{"success":true,"errors":[],"messages":[],"result":{"id":"abc","name":"example.com"},"result_info":{"page":2,"total_pages":3}}`)
+	})
+
+	var zone Zone
+	response, err := client.Do(context.Background(), http.MethodGet, "/zones/abc", &zone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if zone.ID != "abc" || zone.Name != "example.com" {
+		t.Errorf("result = %+v", zone)
+	}
+	if response.ResultInfo == nil || response.ResultInfo.Page != 2 || response.ResultInfo.TotalPages != 3 {
+		t.Errorf("result info = %+v", response.ResultInfo)
+	}
+}
+
+func TestDoReturnsAPIErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		message  string
+		notFound bool
+	}{
+		{"envelope", http.StatusBadRequest, `{"success":false,"errors":[{"code":9005,"message":"Content for A record is invalid"}]}`, "9005: Content for A record is invalid", false},
+		{"success false with 200", http.StatusOK, `{"success":false,"errors":[{"code":7003,"message":"Could not route"}]}`, "7003: Could not route", true},
+		{"not json", http.StatusNotFound, `<html>gone</html>`, "request failed with status 404", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				writeJSON(w, test.status, test.body)
+			})
+
+			_, err := client.Do(context.Background(), http.MethodGet, "/zones", nil)
+			var apiErr *Error
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("err = %v, want *Error", err)
+			}
+			if apiErr.StatusCode != test.status || apiErr.Error() != test.message || apiErr.IsNotFound() != test.notFound {
+				t.Errorf("err = %d %q not found %v, want %d %q %v", apiErr.StatusCode, apiErr.Error(), apiErr.IsNotFound(), test.status, test.message, test.notFound)
+			}
+		})
+	}
+}
+
+func TestDoDeliversPlainText(t *testing.T) {
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte("example.com.\t1\tIN\tA\t192.0.2.1\n"))
+	})
+
+	var export string
+	if _, err := client.Do(context.Background(), http.MethodGet, "/zones/abc/dns_records/export", &export); err != nil {
+		t.Fatal(err)
+	}
+	if export != "example.com.\t1\tIN\tA\t192.0.2.1\n" {
+		t.Errorf("result = %q", export)
+	}
+}
+
+func TestDoJoinsBaseURL(t *testing.T) {
+	for _, suffix := range []string{"", "/"} {
+		var path, query string
+		client, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			path, query = r.URL.Path, r.URL.RawQuery
+			writeJSON(w, http.StatusOK, `{"success":true,"result":null}`)
+		})
+		client.BaseURL = server.URL + "/client/v4" + suffix
+
+		_, err := client.Do(context.Background(), http.MethodGet, "/zones/{zone_id}/dns_records", nil,
+			UsePathParameters("zone_id", "abc"), UseQueryParameters("name", "www.example.com"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if path != "/client/v4/zones/abc/dns_records" || query != "name=www.example.com" {
+			t.Errorf("base %q: requested %s?%s", client.BaseURL, path, query)
+		}
+	}
+}
+
+func TestDoAcceptsEmptySuccess(t *testing.T) {
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/client/v4/gone":
+			w.WriteHeader(http.StatusNotFound)
+		case "/client/v4/empty":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeJSON(w, http.StatusOK, `{"success":true,"result":{"id":"abc"}}`)
+		}
+	})
+
+	for _, test := range []struct{ method, api string }{{http.MethodHead, "/zones"}, {http.MethodDelete, "/empty"}} {
+		var result any
+		response, err := client.Do(context.Background(), test.method, test.api, &result)
+		if err != nil || !response.Success || result != nil {
+			t.Errorf("%s %s = %+v, %v, result %v", test.method, test.api, response, err, result)
+		}
+	}
+	var apiErr *Error
+	if _, err := client.Do(context.Background(), http.MethodHead, "/gone", nil); !errors.As(err, &apiErr) || !apiErr.IsNotFound() {
+		t.Errorf("HEAD answered 404: err = %v", err)
+	}
+}
@@ -0,0 +1,131 @@
+package cloudflare
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// redactedHeaders are replaced in traces, and by references to the variables
+// the CLI reads them from in curl commands.
+var redactedHeaders = map[string]string{
+	"Authorization": "Bearer $CLOUDFLARE_API_TOKEN",
+	"X-Auth-Email":  "$CLOUDFLARE_EMAIL",
+	"X-Auth-Key":    "$CLOUDFLARE_API_KEY",
+}
+
+// DebugTransport writes the requests passing through it to Writer with the
+// credentials redacted. Trace writes each request and response with its
+// timing, Curl writes each request as an equivalent curl command.
+type DebugTransport struct {
+	Base   http.RoundTripper
+	Writer io.Writer
+	Trace  bool
+	Curl   bool
+}
+
+func (t *DebugTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	body, shown := requestBody(r)
+	if t.Curl {
+		t.curl(r, body, shown)
+	}
+	if t.Trace {
+		t.traceRequest(r, body, shown)
+	}
+
+	start := time.Now()
+	response, err := t.Base.RoundTrip(r)
+	if t.Trace {
+		elapsed := time.Since(start).Round(time.Millisecond)
+		if err != nil {
+			fmt.Fprintf(t.Writer, "< %s (%s)\n", err, elapsed)
+		} else {
+			fmt.Fprintf(t.Writer, "< %s %s (%s)\n", response.Proto, response.Status, elapsed)
+			writeHeaders(t.Writer, "< ", response.Header)
+		}
+	}
+	return response, err
+}
+
+func (t *DebugTransport) traceRequest(r *http.Request, body []byte, shown bool) {
+	fmt.Fprintf(t.Writer, "> %s %s\n", r.Method, r.URL.Redacted())
+	if query := r.URL.Query(); len(query) > 0 {
+		keys := make([]string, 0, len(query))
+		for key := range query {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Fprintf(t.Writer, ">   %s=%s\n", key, strings.Join(query[key], ","))
+		}
+	}
+	header := r.Header.Clone()
+	for name := range redactedHeaders {
+		if header.Get(name) != "" {
+			header.Set(name, "REDACTED")
+		}
+	}
+	writeHeaders(t.Writer, "> ", header)
+	switch {
+	case shown:
+		fmt.Fprintf(t.Writer, "> %s\n", bytes.TrimSpace(body))
+	case r.Body != nil:
+		fmt.Fprintf(t.Writer, "> (%s body not shown)\n", r.Header.Get("Content-Type"))
+	}
+}
+
+func (t *DebugTransport) curl(r *http.Request, body []byte, shown bool) {
+	args := []string{"curl", "-X", r.Method, shellQuote(r.URL.String())}
+	names := make([]string, 0, len(r.Header))
+	for name := range r.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if variable, ok := redactedHeaders[name]; ok {
+			args = append(args, "-H", `"`+name+": "+variable+`"`)
+			continue
+		}
+		for _, value := range r.Header[name] {
+			args = append(args, "-H", shellQuote(name+": "+value))
+		}
+	}
+	if shown {
+		args = append(args, "--data", shellQuote(string(bytes.TrimSpace(body))))
+	}
+	fmt.Fprintln(t.Writer, strings.Join(args, " "))
+}
+
+// requestBody returns a copy of the body of r and whether it is JSON, which
+// is the only kind shown. Bodies that cannot be read again are not copied.
+func requestBody(r *http.Request) ([]byte, bool) {
+	if r.Body == nil || r.GetBody == nil || !strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		return nil, false
+	}
+	rc, err := r.GetBody()
+	if err != nil {
+		return nil, false
+	}
+	defer rc.Close()
+	body, err := io.ReadAll(rc)
+	return body, err == nil
+}
+
+func writeHeaders(w io.Writer, prefix string, header http.Header) {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "%s%s: %s\n", prefix, name, strings.Join(header[name], ", "))
+	}
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,48 @@
+package cloudflare
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestDebugTransportRedactsCredentials(t *testing.T) {
+	client, server := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, `{"success":true,"result":{}}`)
+	})
+	client.Credentials = Credentials{
+		APIToken:   "secret-api-token",
+		XAuthEmail: "someone@example.com",
+		XAuthKey:   "secret-auth-key",
+	}
+	out := &bytes.Buffer{}
+	client.HTTPClient = &http.Client{Transport: &DebugTransport{Base: server.Client().Transport, Writer: out, Trace: true, Curl: true}}
+
+	_, err := client.Do(context.Background(), http.MethodPost, "/zones/abc/dns_records", nil,
+		UseQueryParameters("name", "www.example.com"), UseJSONBody(map[string]string{"content": "192.0.2.1"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	trace := out.String()
+	for _, secret := range []string{"secret-api-token", "someone@example.com", "secret-auth-key"} {
+		if strings.Contains(trace, secret) {
+			t.Errorf("output contains %q:\n%s", secret, trace)
+		}
+	}
+	for _, want := range []string{
+		`-H "Authorization: Bearer $CLOUDFLARE_API_TOKEN"`,
+		`-H "X-Auth-Email: $CLOUDFLARE_EMAIL"`,
+		`-H "X-Auth-Key: $CLOUDFLARE_API_KEY"`,
+		`--data '{"content":"192.0.2.1"}'`,
+		"> Authorization: REDACTED",
+		">   name=www.example.com",
+		"< HTTP/1.1 200 OK",
+	} {
+		if !strings.Contains(trace, want) {
+			t.Errorf("output lacks %q:\n%s", want, trace)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package cloudflare
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultRateLimit spreads the budget of 1200 requests per 5 minutes that
+// Cloudflare grants each user.
+const DefaultRateLimit = 4
+
+// RateLimiter is a token bucket shared by every request of a Client, it is
+// safe for concurrent use. Responses narrow it down further: a 429 or a
+// Ratelimit header without remaining requests pauses it until the reset.
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	paused time.Time
+}
+
+// NewRateLimiter allows rate requests per second on average and as many at
+// once, at least one. rate must be positive.
+func NewRateLimiter(rate float64) *RateLimiter {
+	burst := math.Max(math.Ceil(rate), 1)
+	return &RateLimiter{rate: rate, burst: burst, tokens: burst, last: time.Now()}
+}
+
+// Wait blocks until a request may be sent or ctx is done. A nil RateLimiter
+// never blocks.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	for {
+		wait := l.reserve(time.Now())
+		if wait <= 0 {
+			return nil
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// reserve takes a token and returns zero, or returns how long to wait for one.
+func (l *RateLimiter) reserve(now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	switch {
+	case now.Before(l.paused):
+		return l.paused.Sub(now)
+	case l.tokens >= 1:
+		l.tokens--
+		return 0
+	default:
+		return time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+	}
+}
+
+// Observe adapts the limiter to the rate limit state reported by response.
+func (l *RateLimiter) Observe(response *http.Response) {
+	if l == nil {
+		return
+	}
+	remaining, reset, ok := parseRateLimit(response.Header.Get("Ratelimit"))
+	if response.StatusCode == http.StatusTooManyRequests {
+		if wait, found := retryAfter(response.Header.Get("Retry-After")); found {
+			reset = wait
+		} else if !ok {
+			reset = time.Second
+		}
+		remaining, ok = 0, true
+	}
+	if !ok {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tokens = math.Min(l.tokens, float64(remaining))
+	if remaining == 0 {
+		if until := time.Now().Add(reset); until.After(l.paused) {
+			l.paused = until
+		}
+	}
+}
+
+// parseRateLimit reads the remaining requests and the time until they reset
+// from a header like `"default";r=50;t=30`.
+func parseRateLimit(value string) (int, time.Duration, bool) {
+	remaining, reset := -1, 0
+	for _, param := range strings.Split(value, ";") {
+		key, raw, found := strings.Cut(strings.TrimSpace(param), "=")
+		if !found {
+			continue
+		}
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			continue
+		}
+		switch key {
+		case "r":
+			remaining = n
+		case "t":
+			reset = n
+		}
+	}
+	if remaining < 0 {
+		return 0, 0, false
+	}
+	return remaining, time.Duration(reset) * time.Second, true
+}
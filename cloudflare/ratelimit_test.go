@@ -0,0 +1,113 @@
+package cloudflare
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterReserve(t *testing.T) {
+	start := time.Now()
+	limiter := NewRateLimiter(2)
+	limiter.last = start
+
+	for i := 0; i < 2; i++ {
+		if wait := limiter.reserve(start); wait != 0 {
+			t.Fatalf("request %d of the burst waits %s", i+1, wait)
+		}
+	}
+	if wait := limiter.reserve(start); wait != 500*time.Millisecond {
+		t.Errorf("request after the burst waits %s, want 500ms", wait)
+	}
+	if wait := limiter.reserve(start.Add(250 * time.Millisecond)); wait != 250*time.Millisecond {
+		t.Errorf("request 250ms later waits %s, want 250ms", wait)
+	}
+	if wait := limiter.reserve(start.Add(500 * time.Millisecond)); wait != 0 {
+		t.Errorf("request 500ms later waits %s", wait)
+	}
+	// Idle time refills the bucket only up to the burst.
+	later := start.Add(time.Minute)
+	for i := 0; i < 2; i++ {
+		if wait := limiter.reserve(later); wait != 0 {
+			t.Errorf("request %d after idling waits %s", i+1, wait)
+		}
+	}
+	if wait := limiter.reserve(later); wait == 0 {
+		t.Errorf("idling filled the bucket beyond the burst")
+	}
+}
+
+func TestRateLimiterPauses(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		header http.Header
+		pause  time.Duration
+	}{
+		{"429 with Retry-After", http.StatusTooManyRequests, http.Header{"Retry-After": {"5"}}, 5 * time.Second},
+		{"429 without Retry-After", http.StatusTooManyRequests, http.Header{}, time.Second},
+		{"no remaining requests", http.StatusOK, http.Header{"Ratelimit": {`"default";r=0;t=30`}}, 30 * time.Second},
+		{"remaining requests", http.StatusOK, http.Header{"Ratelimit": {`"default";r=50;t=30`}}, 0},
+		{"no header", http.StatusOK, http.Header{}, 0},
+	}
+	for _, test := range tests {
+		limiter := NewRateLimiter(100)
+		limiter.Observe(&http.Response{StatusCode: test.status, Header: test.header})
+
+		wait := limiter.reserve(time.Now())
+		if wait > test.pause || wait < test.pause-time.Second {
+			t.Errorf("%s: waits %s, want %s", test.name, wait, test.pause)
+		}
+	}
+}
+
+func TestRateLimiterRemainingShrinksBucket(t *testing.T) {
+	limiter := NewRateLimiter(10)
+	limiter.Observe(&http.Response{StatusCode: http.StatusOK, Header: http.Header{"Ratelimit": {`"default";r=1;t=60`}}})
+
+	now := limiter.last
+	if wait := limiter.reserve(now); wait != 0 {
+		t.Errorf("last remaining request waits %s", wait)
+	}
+	if wait := limiter.reserve(now); wait == 0 {
+		t.Errorf("request beyond the remaining ones was not delayed")
+	}
+}
+
+func TestRateLimiterConcurrentWait(t *testing.T) {
+	limiter := NewRateLimiter(100)
+	start := time.Now()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 150; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := limiter.Wait(context.Background()); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	// 100 requests leave at once, the other 50 at 100 per second.
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond || elapsed > 5*time.Second {
+		t.Errorf("150 requests at 100 per second took %s", elapsed)
+	}
+}
+
+func TestRateLimiterWaitCanceled(t *testing.T) {
+	limiter := NewRateLimiter(1)
+	limiter.Observe(&http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": {"60"}}})
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := limiter.Wait(ctx); err != context.DeadlineExceeded {
+		t.Errorf("err = %v, want %v", err, context.DeadlineExceeded)
+	}
+	var nilLimiter *RateLimiter
+	if err := nilLimiter.Wait(ctx); err != nil {
+		t.Errorf("nil limiter: err = %v", err)
+	}
+}
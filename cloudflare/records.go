@@ -0,0 +1,208 @@
+package cloudflare
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type DNSRecord struct {
+	ID                string         `json:"id"`
+	ZoneID            string         `json:"zone_id,omitempty"`
+	ZoneName          string         `json:"zone_name,omitempty"`
+	Name              string         `json:"name"`
+	Type              string         `json:"type"`
+	Content           string         `json:"content,omitempty"`
+	Proxiable         bool           `json:"proxiable"`
+	Proxied           bool           `json:"proxied"`
+	TTL               uint64         `json:"ttl"`
+	Priority          *uint64        `json:"priority,omitempty"`
+	Data              map[string]any `json:"data,omitempty"`
+	Settings          map[string]any `json:"settings,omitempty"`
+	Meta              map[string]any `json:"meta,omitempty"`
+	Comment           string         `json:"comment,omitempty"`
+	Tags              []string       `json:"tags"`
+	CreatedOn         time.Time      `json:"created_on"`
+	ModifiedOn        time.Time      `json:"modified_on"`
+	CommentModifiedOn *time.Time     `json:"comment_modified_on,omitempty"`
+	TagsModifiedOn    *time.Time     `json:"tags_modified_on,omitempty"`
+}
+
+type ScanResult struct {
+	RecsAdded          int `json:"recs_added"`
+	TotalRecordsParsed int `json:"total_records_parsed"`
+}
+
+// ImportResult has the same shape as the result of a scan.
+type ImportResult = ScanResult
+
+// ListRecords fetches a single page of records. Keys of query are the API's
+// query parameters, Eg. "name", "comment.contains", "per_page".
+func (c *Client) ListRecords(ctx context.Context, zoneID string, query map[string]string) ([]DNSRecord, *ResultInfo, error) {
+	var records []DNSRecord
+	response, err := c.Do(ctx, http.MethodGet, "/zones/{zone_id}/dns_records", &records,
+		UsePathParameters("zone_id", zoneID),
+		UseQueryParametersWithMap(query),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	return records, response.ResultInfo, nil
+}
+
+// EachRecordPage walks the pages of ListRecords starting at the page in query,
+// calling fn for every page. It fails rather than fetching more than maxPages
+// pages; maxPages <= 0 means no limit.
+func (c *Client) EachRecordPage(ctx context.Context, zoneID string, query map[string]string, maxPages int, fn func(records []DNSRecord, info *ResultInfo) error) error {
+	params := make(map[string]string, len(query))
+	for key, value := range query {
+		params[key] = value
+	}
+	page := 1
+	if n, err := strconv.Atoi(params["page"]); err == nil && n > 0 {
+		page = n
+	}
+
+	for fetched := 0; ; fetched++ {
+		if maxPages > 0 && fetched >= maxPages {
+			return fmt.Errorf("stopped after %d pages, raise the page limit to fetch the rest", maxPages)
+		}
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("stopped after %d pages: %w", fetched, err)
+		}
+		params["page"] = strconv.Itoa(page)
+		records, info, err := c.ListRecords(ctx, zoneID, params)
+		if err != nil {
+			return err
+		}
+		if err = fn(records, info); err != nil {
+			return err
+		}
+		if info == nil || page >= info.TotalPages || len(records) == 0 {
+			return nil
+		}
+		page++
+	}
+}
+
+// ListAllRecords merges every page of ListRecords into a single result.
+func (c *Client) ListAllRecords(ctx context.Context, zoneID string, query map[string]string, maxPages int) ([]DNSRecord, *ResultInfo, error) {
+	all := []DNSRecord{}
+	merged := &ResultInfo{Page: 1, TotalPages: 1}
+	err := c.EachRecordPage(ctx, zoneID, query, maxPages, func(records []DNSRecord, info *ResultInfo) error {
+		all = append(all, records...)
+		if info != nil {
+			merged.TotalCount = info.TotalCount
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	merged.Count = len(all)
+	merged.PerPage = len(all)
+	return all, merged, nil
+}
+
+func (c *Client) GetRecord(ctx context.Context, zoneID, recordID string) (*DNSRecord, error) {
+	record := &DNSRecord{}
+	_, err := c.Do(ctx, http.MethodGet, "/zones/{zone_id}/dns_records/{dns_record_id}", record,
+		UsePathParameters("zone_id", zoneID),
+		UsePathParameters("dns_record_id", recordID),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// CreateRecord creates a record from body, which is anything that encodes to
+// the API's record object, Eg. a DNSRecord or a map.
+func (c *Client) CreateRecord(ctx context.Context, zoneID string, body any) (*DNSRecord, error) {
+	record := &DNSRecord{}
+	_, err := c.Do(ctx, http.MethodPost, "/zones/{zone_id}/dns_records", record,
+		UsePathParameters("zone_id", zoneID),
+		UseJSONBody(body),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// UpdateRecord changes only the fields present in body.
+func (c *Client) UpdateRecord(ctx context.Context, zoneID, recordID string, body any) (*DNSRecord, error) {
+	return c.writeRecord(ctx, http.MethodPatch, zoneID, recordID, body)
+}
+
+// OverwriteRecord replaces the whole record, resetting fields absent from body.
+func (c *Client) OverwriteRecord(ctx context.Context, zoneID, recordID string, body any) (*DNSRecord, error) {
+	return c.writeRecord(ctx, http.MethodPut, zoneID, recordID, body)
+}
+
+func (c *Client) writeRecord(ctx context.Context, method, zoneID, recordID string, body any) (*DNSRecord, error) {
+	record := &DNSRecord{}
+	_, err := c.Do(ctx, method, "/zones/{zone_id}/dns_records/{dns_record_id}", record,
+		UsePathParameters("zone_id", zoneID),
+		UsePathParameters("dns_record_id", recordID),
+		UseJSONBody(body),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// DeleteRecord returns the identifier of the deleted record.
+func (c *Client) DeleteRecord(ctx context.Context, zoneID, recordID string) (string, error) {
+	var result struct {
+		ID string `json:"id"`
+	}
+	_, err := c.Do(ctx, http.MethodDelete, "/zones/{zone_id}/dns_records/{dns_record_id}", &result,
+		UsePathParameters("zone_id", zoneID),
+		UsePathParameters("dns_record_id", recordID),
+	)
+	if err != nil {
+		return "", err
+	}
+	return result.ID, nil
+}
+
+// ExportRecords returns the zone's records as a BIND config.
+func (c *Client) ExportRecords(ctx context.Context, zoneID string) (string, error) {
+	var bind string
+	_, err := c.Do(ctx, http.MethodGet, "/zones/{zone_id}/dns_records/export", &bind,
+		UsePathParameters("zone_id", zoneID),
+	)
+	if err != nil {
+		return "", err
+	}
+	return bind, nil
+}
+
+// ImportRecords uploads a BIND config, proxying the imported records that
+// can be proxied when proxied is set.
+func (c *Client) ImportRecords(ctx context.Context, zoneID string, bind []byte, proxied bool) (*ImportResult, error) {
+	result := &ImportResult{}
+	_, err := c.Do(ctx, http.MethodPost, "/zones/{zone_id}/dns_records/import", result,
+		UsePathParameters("zone_id", zoneID),
+		UseMultipartBody(map[string]string{"proxied": strconv.FormatBool(proxied)}, "file", "zone.txt", bind),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *Client) ScanRecords(ctx context.Context, zoneID string) (*ScanResult, error) {
+	result := &ScanResult{}
+	_, err := c.Do(ctx, http.MethodPost, "/zones/{zone_id}/dns_records/scan", result,
+		UsePathParameters("zone_id", zoneID),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,125 @@
+package cloudflare
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// pagedServer serves totalPages pages of two records each and records the
+// pages requested.
+func pagedServer(t *testing.T, totalPages int) (*Client, func() []int) {
+	var mu sync.Mutex
+	var pages []int
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		mu.Lock()
+		pages = append(pages, page)
+		mu.Unlock()
+		records := []DNSRecord{}
+		if page <= totalPages {
+			records = append(records, DNSRecord{ID: strconv.Itoa(page) + "a"}, DNSRecord{ID: strconv.Itoa(page) + "b"})
+		}
+		result, _ := json.Marshal(records)
+		info, _ := json.Marshal(ResultInfo{Page: page, PerPage: 2, Count: len(records), TotalCount: 2 * totalPages, TotalPages: totalPages})
+		writeJSON(w, http.StatusOK, `{"success":true,"result":`+string(result)+`,"result_info":`+string(info)+`}`)
+	})
+	return client, func() []int {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]int(nil), pages...)
+	}
+}
+
+func recordIDs(records []DNSRecord) string {
+	ids := make([]string, len(records))
+	for i, record := range records {
+		ids[i] = record.ID
+	}
+	return strings.Join(ids, " ")
+}
+
+func TestListAllRecordsFollowsTotalPages(t *testing.T) {
+	client, pages := pagedServer(t, 3)
+
+	records, info, err := client.ListAllRecords(context.Background(), "zone", map[string]string{"per_page": "2"}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := recordIDs(records); got != "1a 1b 2a 2b 3a 3b" {
+		t.Errorf("records = %s", got)
+	}
+	if info.Count != 6 || info.TotalCount != 6 || info.TotalPages != 1 {
+		t.Errorf("merged result info = %+v", info)
+	}
+	if got := pages(); len(got) != 3 {
+		t.Errorf("requested pages %v, want 1 to 3", got)
+	}
+}
+
+func TestEachRecordPageStartsAtPage(t *testing.T) {
+	client, pages := pagedServer(t, 4)
+
+	var seen []string
+	err := client.EachRecordPage(context.Background(), "zone", map[string]string{"page": "3"}, 0, func(records []DNSRecord, info *ResultInfo) error {
+		seen = append(seen, recordIDs(records))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(seen, ", ") != "3a 3b, 4a 4b" {
+		t.Errorf("pages seen = %q", seen)
+	}
+	if got := pages(); len(got) != 2 || got[0] != 3 || got[1] != 4 {
+		t.Errorf("requested pages %v, want 3 and 4", got)
+	}
+}
+
+func TestEachRecordPageStopsWhenCanceled(t *testing.T) {
+	client, pages := pagedServer(t, 3)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var seen []string
+	err := client.EachRecordPage(ctx, "zone", nil, 0, func(records []DNSRecord, info *ResultInfo) error {
+		seen = append(seen, recordIDs(records))
+		cancel()
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) || err.Error() != "stopped after 1 pages: context canceled" {
+		t.Errorf("err = %v, want to stop after the first page", err)
+	}
+	if len(seen) != 1 {
+		t.Errorf("pages seen = %q, want only the first", seen)
+	}
+	if got := pages(); len(got) != 1 {
+		t.Errorf("requested pages %v, want only the first", got)
+	}
+}
+
+func TestEachRecordPageMaxPages(t *testing.T) {
+	client, pages := pagedServer(t, 5)
+
+	records, _, err := client.ListAllRecords(context.Background(), "zone", nil, 2)
+	if err == nil || err.Error() != "stopped after 2 pages, raise the page limit to fetch the rest" {
+		t.Errorf("err = %v, want the page limit error", err)
+	}
+	if records != nil {
+		t.Errorf("records = %v, want none with the error", records)
+	}
+	if got := pages(); len(got) != 2 {
+		t.Errorf("requested pages %v, want 2", got)
+	}
+
+	// The limit is not hit when the last page is within it.
+	client, _ = pagedServer(t, 2)
+	if _, _, err = client.ListAllRecords(context.Background(), "zone", nil, 2); err != nil {
+		t.Errorf("two pages with a limit of two: %s", err)
+	}
+}
@@ -0,0 +1,76 @@
+package cloudflare
+
+import (
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how requests answered with 429 or a 5xx status are
+// retried. The zero value never retries.
+type RetryPolicy struct {
+	MaxRetries int
+	MinWait    time.Duration
+	MaxWait    time.Duration
+
+	// AllMethods also retries POST and PATCH requests, which are applied twice
+	// when the failed attempt did reach Cloudflare.
+	AllMethods bool
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries: 3,
+	MinWait:    time.Second,
+	MaxWait:    30 * time.Second,
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// wait reports how long to wait before retrying a request that failed with
+// response, or false when it must not be retried. Retry-After wins over the
+// exponential backoff, which is jittered so concurrent clients spread out, but
+// a Retry-After beyond MaxWait gives up and leaves the rate limit error.
+func (p RetryPolicy) wait(request *http.Request, response *http.Response, retries int) (time.Duration, bool) {
+	if retries >= p.MaxRetries {
+		return 0, false
+	}
+	if response.StatusCode != http.StatusTooManyRequests && response.StatusCode < http.StatusInternalServerError {
+		return 0, false
+	}
+	if !p.AllMethods && !idempotent(request.Method) {
+		return 0, false
+	}
+	if request.Body != nil && request.GetBody == nil {
+		return 0, false
+	}
+
+	if wait, ok := retryAfter(response.Header.Get("Retry-After")); ok {
+		return wait, wait <= p.MaxWait
+	}
+	wait := p.MinWait << retries
+	if wait > p.MaxWait || wait <= 0 {
+		wait = p.MaxWait
+	}
+	return wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1)), true
+}
+
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0), true
+	}
+	return 0, false
+}
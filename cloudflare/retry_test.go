@@ -0,0 +1,152 @@
+package cloudflare
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var fastRetries = RetryPolicy{MaxRetries: 2, MinWait: time.Millisecond, MaxWait: 10 * time.Millisecond}
+
+// failingServer answers the first failures requests with status and the
+// rest with success, and records the bodies it received.
+func failingServer(t *testing.T, failures, status int, header http.Header) (*Client, func() []string) {
+	var mu sync.Mutex
+	var bodies []string
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, string(body))
+		attempt := len(bodies)
+		mu.Unlock()
+		if attempt <= failures {
+			for key, values := range header {
+				w.Header()[key] = values
+			}
+			writeJSON(w, status, `{"success":false,"errors":[{"code":10000,"message":"failed"}]}`)
+			return
+		}
+		writeJSON(w, http.StatusOK, `{"success":true,"result":{}}`)
+	})
+	client.Retry = fastRetries
+	return client, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), bodies...)
+	}
+}
+
+func TestRetriedMethods(t *testing.T) {
+	tests := []struct {
+		method     string
+		status     int
+		allMethods bool
+		attempts   int
+	}{
+		{http.MethodGet, http.StatusTooManyRequests, false, 2},
+		{http.MethodGet, http.StatusBadGateway, false, 2},
+		{http.MethodPut, http.StatusServiceUnavailable, false, 2},
+		{http.MethodDelete, http.StatusInternalServerError, false, 2},
+		{http.MethodGet, http.StatusBadRequest, false, 1},
+		{http.MethodPost, http.StatusServiceUnavailable, false, 1},
+		{http.MethodPatch, http.StatusTooManyRequests, false, 1},
+		{http.MethodPost, http.StatusServiceUnavailable, true, 2},
+		{http.MethodPatch, http.StatusTooManyRequests, true, 2},
+	}
+	for _, test := range tests {
+		client, bodies := failingServer(t, 1, test.status, nil)
+		client.Retry.AllMethods = test.allMethods
+
+		_, err := client.Do(context.Background(), test.method, "/zones", nil, UseJSONBody(map[string]string{"name": "www"}))
+		if attempts := len(bodies()); attempts != test.attempts {
+			t.Errorf("%s answered %d, all methods %v: %d attempts, want %d", test.method, test.status, test.allMethods, attempts, test.attempts)
+		}
+		if (err == nil) != (test.attempts == 2) {
+			t.Errorf("%s answered %d, all methods %v: err = %v", test.method, test.status, test.allMethods, err)
+		}
+	}
+}
+
+func TestRetryResendsBody(t *testing.T) {
+	client, bodies := failingServer(t, 2, http.StatusServiceUnavailable, nil)
+
+	if _, err := client.Do(context.Background(), http.MethodPut, "/zones/abc/dns_records/def", nil, UseJSONBody(map[string]string{"name": "www"})); err != nil {
+		t.Fatal(err)
+	}
+	got := bodies()
+	if len(got) != 3 {
+		t.Fatalf("%d attempts, want 3", len(got))
+	}
+	for i, body := range got {
+		if strings.TrimSpace(body) != `{"name":"www"}` {
+			t.Errorf("attempt %d sent %q", i+1, body)
+		}
+	}
+}
+
+func TestRetryGivesUpAfterMaxRetries(t *testing.T) {
+	client, bodies := failingServer(t, 5, http.StatusServiceUnavailable, nil)
+
+	_, err := client.Do(context.Background(), http.MethodGet, "/zones", nil)
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("err = %v, want the last 503", err)
+	}
+	if attempts := len(bodies()); attempts != 3 {
+		t.Errorf("%d attempts, want 3", attempts)
+	}
+}
+
+func TestRetryHonorsRetryAfter(t *testing.T) {
+	policy := RetryPolicy{MaxRetries: 3, MinWait: time.Second, MaxWait: 30 * time.Second}
+	request, _ := http.NewRequest(http.MethodGet, "https://example.com", nil)
+	response := func(retryAfter string) *http.Response {
+		return &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": {retryAfter}}}
+	}
+
+	if wait, ok := policy.wait(request, response("7"), 0); !ok || wait != 7*time.Second {
+		t.Errorf("Retry-After 7: wait %s, %v", wait, ok)
+	}
+	at := time.Now().Add(20 * time.Second).UTC().Format(http.TimeFormat)
+	if wait, ok := policy.wait(request, response(at), 0); !ok || wait <= 15*time.Second || wait > 20*time.Second {
+		t.Errorf("Retry-After %s: wait %s, %v", at, wait, ok)
+	}
+	if wait, ok := policy.wait(request, response("120"), 0); ok {
+		t.Errorf("Retry-After beyond MaxWait retried after %s", wait)
+	}
+	if wait, ok := policy.wait(request, response("soon"), 2); !ok || wait < 2*time.Second || wait > 4*time.Second {
+		t.Errorf("invalid Retry-After: wait %s, %v, want the backoff", wait, ok)
+	}
+
+	client, bodies := failingServer(t, 1, http.StatusTooManyRequests, http.Header{"Retry-After": {"3600"}})
+	_, err := client.Do(context.Background(), http.MethodGet, "/zones", nil)
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || !apiErr.IsRateLimited() || len(bodies()) != 1 {
+		t.Errorf("Retry-After beyond MaxWait: err = %v after %d attempts, want the rate limit error at once", err, len(bodies()))
+	}
+}
+
+func TestRetryWaitCanceled(t *testing.T) {
+	client, bodies := failingServer(t, 1, http.StatusTooManyRequests, http.Header{"Retry-After": {"10"}})
+	client.Retry.MaxWait = time.Minute
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := client.Do(ctx, http.MethodGet, "/zones", nil)
+	var networkErr *NetworkError
+	if !errors.As(err, &networkErr) || !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want a NetworkError wrapping context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("canceled wait took %s", elapsed)
+	}
+	if attempts := len(bodies()); attempts != 1 {
+		t.Errorf("%d attempts, want 1", attempts)
+	}
+}
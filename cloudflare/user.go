@@ -0,0 +1,74 @@
+package cloudflare
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+type User struct {
+	ID        string `json:"id"`
+	Email     string `json:"email"`
+	FirstName string `json:"first_name,omitempty"`
+	LastName  string `json:"last_name,omitempty"`
+	Suspended bool   `json:"suspended"`
+}
+
+type TokenVerification struct {
+	ID        string     `json:"id"`
+	Status    string     `json:"status"`
+	ExpiresOn *time.Time `json:"expires_on,omitempty"`
+	NotBefore *time.Time `json:"not_before,omitempty"`
+}
+
+type PermissionGroup struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type TokenPolicy struct {
+	ID               string            `json:"id"`
+	Effect           string            `json:"effect"`
+	Resources        map[string]any    `json:"resources"`
+	PermissionGroups []PermissionGroup `json:"permission_groups"`
+}
+
+type Token struct {
+	ID         string        `json:"id"`
+	Name       string        `json:"name"`
+	Status     string        `json:"status"`
+	IssuedOn   *time.Time    `json:"issued_on,omitempty"`
+	ModifiedOn *time.Time    `json:"modified_on,omitempty"`
+	ExpiresOn  *time.Time    `json:"expires_on,omitempty"`
+	NotBefore  *time.Time    `json:"not_before,omitempty"`
+	Policies   []TokenPolicy `json:"policies"`
+}
+
+// VerifyToken checks the API token of the client.
+func (c *Client) VerifyToken(ctx context.Context) (*TokenVerification, error) {
+	verification := &TokenVerification{}
+	if _, err := c.Do(ctx, http.MethodGet, "/user/tokens/verify", verification); err != nil {
+		return nil, err
+	}
+	return verification, nil
+}
+
+// GetToken needs a token that is allowed to read API tokens, which most are not.
+func (c *Client) GetToken(ctx context.Context, tokenID string) (*Token, error) {
+	token := &Token{}
+	_, err := c.Do(ctx, http.MethodGet, "/user/tokens/{token_id}", token,
+		UsePathParameters("token_id", tokenID),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+func (c *Client) GetUser(ctx context.Context) (*User, error) {
+	user := &User{}
+	if _, err := c.Do(ctx, http.MethodGet, "/user", user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
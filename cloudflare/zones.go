@@ -0,0 +1,49 @@
+package cloudflare
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+type Account struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type Zone struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Status      string   `json:"status"`
+	Paused      bool     `json:"paused"`
+	Type        string   `json:"type"`
+	NameServers []string `json:"name_servers"`
+	Account     Account  `json:"account"`
+}
+
+// ListZones fetches a single page of the zones the credentials can see. Keys
+// of query are the API's query parameters, Eg. "name", "status", "per_page".
+func (c *Client) ListZones(ctx context.Context, query map[string]string) ([]Zone, *ResultInfo, error) {
+	var zones []Zone
+	response, err := c.Do(ctx, http.MethodGet, "/zones", &zones, UseQueryParametersWithMap(query))
+	if err != nil {
+		return nil, nil, err
+	}
+	return zones, response.ResultInfo, nil
+}
+
+// ZoneID resolves a domain name, Eg. example.com, to its zone identifier.
+func (c *Client) ZoneID(ctx context.Context, name string) (string, error) {
+	zones, _, err := c.ListZones(ctx, map[string]string{"name": name})
+	if err != nil {
+		return "", err
+	}
+	switch len(zones) {
+	case 0:
+		return "", fmt.Errorf("zone '%s': %w", name, ErrNotFound)
+	case 1:
+		return zones[0].ID, nil
+	default:
+		return "", fmt.Errorf("zone '%s' matches %d zones, use its identifier instead", name, len(zones))
+	}
+}
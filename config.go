@@ -0,0 +1,211 @@
+package main
+
+import (
+	"cf-cli/cloudflare"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+const DefaultProfile = "default"
+
+type SecurityConfiguration struct {
+	XAuthEmail string `json:"x_auth_email,omitempty"`
+	XAuthKey   string `json:"x_auth_key,omitempty"`
+	APIToken   string `json:"api_token,omitempty"`
+	APIBase    string `json:"api_base,omitempty"`
+
+	// Zone is the name or identifier of the zone used when a command is given
+	// neither --zone-id nor --zone.
+	Zone string `json:"zone,omitempty"`
+
+	// SecretBackend names where XAuthKey and APIToken are kept when they are
+	// not in the config file itself, Eg. keyring or file.
+	SecretBackend string `json:"secret_backend,omitempty"`
+}
+
+func (c *SecurityConfiguration) Locked() bool {
+	return c.SecretBackend != "" && c.SecretBackend != SecretBackendConfig
+}
+
+func (c *SecurityConfiguration) HasCredentials() bool {
+	return c.APIToken != "" || c.XAuthEmail != "" && c.XAuthKey != ""
+}
+
+func (c *SecurityConfiguration) Credentials() cloudflare.Credentials {
+	return cloudflare.Credentials{
+		XAuthEmail: c.XAuthEmail,
+		XAuthKey:   c.XAuthKey,
+		APIToken:   c.APIToken,
+	}
+}
+
+// ConfigFile holds every named profile. Files written before profiles existed
+// contain a bare SecurityConfiguration, which is read as the default profile.
+type ConfigFile struct {
+	CurrentProfile string                            `json:"current_profile,omitempty"`
+	Profiles       map[string]*SecurityConfiguration `json:"profiles"`
+
+	// Defaults are stored by 'config set', keyed by the flag they apply to.
+	Defaults map[string]string `json:"defaults,omitempty"`
+
+	Path string `json:"-"`
+}
+
+const legacyConfigFileName = ".cf_cli_config"
+
+// ConfigFilePath resolves the config file given by --config or CF_CLI_CONFIG,
+// then $XDG_CONFIG_HOME/cf-cli/config.json. ~/.cf_cli_config is still used
+// when it exists and the new file does not.
+func ConfigFilePath(explicit string) (string, error) {
+	if explicit != "" {
+		return explicit, nil
+	}
+	dir, err := xdgDir("XDG_CONFIG_HOME", os.UserConfigDir)
+	if err != nil {
+		return "", errors.New("failed to get user config dir, cause: " + err.Error())
+	}
+	name := filepath.Join(dir, "cf-cli", "config.json")
+	if FileExist(name) {
+		return name, nil
+	}
+	if home, err := os.UserHomeDir(); err == nil && FileExist(filepath.Join(home, legacyConfigFileName)) {
+		return filepath.Join(home, legacyConfigFileName), nil
+	}
+	return name, nil
+}
+
+// xdgDir returns the directory named by the XDG variable env when it is an
+// absolute path, and else the one of the OS, which ignores it on macOS.
+func xdgDir(env string, osDir func() (string, error)) (string, error) {
+	if dir := os.Getenv(env); filepath.IsAbs(dir) {
+		return dir, nil
+	}
+	return osDir()
+}
+
+// OpenConfigFile returns an empty ConfigFile when there is no file yet.
+func OpenConfigFile(name string) (*ConfigFile, error) {
+	raw, err := os.ReadFile(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return &ConfigFile{Profiles: map[string]*SecurityConfiguration{}, Path: name}, nil
+	} else if err != nil {
+		return nil, errors.New("failed to open security configuration, cause: " + err.Error())
+	}
+
+	file := &ConfigFile{Path: name}
+	if err = json.Unmarshal(raw, file); err != nil {
+		return nil, errors.New("failed to parse security configuration, cause: " + err.Error())
+	}
+	if file.Profiles == nil {
+		legacy := &SecurityConfiguration{}
+		if err = json.Unmarshal(raw, legacy); err != nil {
+			return nil, errors.New("failed to parse security configuration, cause: " + err.Error())
+		}
+		file.CurrentProfile = DefaultProfile
+		file.Profiles = map[string]*SecurityConfiguration{DefaultProfile: legacy}
+	}
+	return file, nil
+}
+
+func (f *ConfigFile) Save() error {
+	w, err := ShouldOpenFile(f.Path, os.O_WRONLY|os.O_TRUNC|os.O_CREATE, 0600)
+	if err != nil {
+		return errors.New("failed to open security configuration file, cause: " + err.Error())
+	}
+	defer w.Close()
+	if err = w.Chmod(0600); err != nil {
+		return errors.New("failed to restrict security configuration file, cause: " + err.Error())
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(f)
+}
+
+// SecretStore opens backend, keeping the secrets file next to the config file.
+func (f *ConfigFile) SecretStore(backend string) (SecretStore, error) {
+	name := filepath.Join(filepath.Dir(f.Path), "secrets.json")
+	if filepath.Base(f.Path) == legacyConfigFileName {
+		name = filepath.Join(filepath.Dir(f.Path), ".cf_cli_secrets")
+	}
+	return OpenSecretStore(backend, name)
+}
+
+// Unlock fetches the secrets of a locked profile from its backend.
+func (f *ConfigFile) Unlock(profile string, c *SecurityConfiguration) error {
+	if !c.Locked() {
+		return nil
+	}
+	store, err := f.SecretStore(c.SecretBackend)
+	if err != nil {
+		return err
+	}
+	secrets, err := store.Get(profile)
+	if err != nil {
+		return err
+	}
+	c.XAuthKey, c.APIToken = secrets.XAuthKey, secrets.APIToken
+	c.SecretBackend = ""
+	return nil
+}
+
+// Lock moves the secrets of the profile into backend.
+func (f *ConfigFile) Lock(profile, backend string, c *SecurityConfiguration) error {
+	if backend == "" || backend == SecretBackendConfig {
+		return nil
+	}
+	store, err := f.SecretStore(backend)
+	if err != nil {
+		return err
+	}
+	if err = store.Set(profile, &Secrets{XAuthKey: c.XAuthKey, APIToken: c.APIToken}); err != nil {
+		return err
+	}
+	c.XAuthKey, c.APIToken = "", ""
+	c.SecretBackend = backend
+	return nil
+}
+
+// Forget deletes the secrets of the profile from its backend.
+func (f *ConfigFile) Forget(profile string, c *SecurityConfiguration) error {
+	if !c.Locked() {
+		return nil
+	}
+	store, err := f.SecretStore(c.SecretBackend)
+	if err != nil {
+		return err
+	}
+	return store.Delete(profile)
+}
+
+// ProfileName picks the explicitly requested profile, then the current one.
+func (f *ConfigFile) ProfileName(requested string) string {
+	switch {
+	case requested != "":
+		return requested
+	case f.CurrentProfile != "":
+		return f.CurrentProfile
+	default:
+		return DefaultProfile
+	}
+}
+
+func (f *ConfigFile) Profile(name string) (*SecurityConfiguration, error) {
+	profile, ok := f.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("profile '%s' does not exist", name)
+	}
+	return profile, nil
+}
+
+func (f *ConfigFile) ProfileNames() []string {
+	names := make([]string, 0, len(f.Profiles))
+	for name := range f.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfigFilePath(t *testing.T) {
+	isolate(t)
+	home, _ := os.UserHomeDir()
+	xdg := filepath.Join(os.Getenv("XDG_CONFIG_HOME"), "cf-cli", "config.json")
+	legacy := filepath.Join(home, legacyConfigFileName)
+	explicit := filepath.Join(t.TempDir(), "explicit.json")
+	s := newStandIn(t, nil)
+	path := func(args ...string) string {
+		t.Helper()
+		stdout, err := s.run(t, nil, append(args, "config", "path")...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.TrimSpace(stdout)
+	}
+	touch := func(name string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(name), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte("{}"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := path(); got != xdg {
+		t.Errorf("without files: %s, want %s", got, xdg)
+	}
+	touch(legacy)
+	if got := path(); got != legacy {
+		t.Errorf("with the legacy file only: %s, want %s", got, legacy)
+	}
+	touch(xdg)
+	if got := path(); got != xdg {
+		t.Errorf("with both files: %s, want %s", got, xdg)
+	}
+	if got := path("--config", explicit); got != explicit {
+		t.Errorf("--config: %s, want %s", got, explicit)
+	}
+	t.Setenv("CF_CLI_CONFIG", explicit)
+	if got := path(); got != explicit {
+		t.Errorf("CF_CLI_CONFIG: %s, want %s", got, explicit)
+	}
+}
+
+func TestXDGDirOverridesOSDefault(t *testing.T) {
+	osDir := func() (string, error) { return "/os/default", nil }
+
+	t.Setenv("XDG_CONFIG_HOME", "/xdg/config")
+	if dir, _ := xdgDir("XDG_CONFIG_HOME", osDir); dir != "/xdg/config" {
+		t.Errorf("with XDG_CONFIG_HOME: %s, want /xdg/config", dir)
+	}
+	for _, value := range []string{"", "relative/config"} {
+		t.Setenv("XDG_CONFIG_HOME", value)
+		if dir, _ := xdgDir("XDG_CONFIG_HOME", osDir); dir != "/os/default" {
+			t.Errorf("with XDG_CONFIG_HOME %q: %s, want the OS default", value, dir)
+		}
+	}
+}
+
+func TestConfigFileRoundTrip(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "config.json")
+	file, err := OpenConfigFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.CurrentProfile = "prod"
+	file.Profiles["prod"] = &SecurityConfiguration{APIToken: "token", Zone: "example.com"}
+	file.Defaults = map[string]string{"output": "table"}
+	if err = file.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("config file mode = %v, want 0600", info.Mode().Perm())
+	}
+	reopened, err := OpenConfigFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reopened.CurrentProfile != "prod" || reopened.Profiles["prod"].Zone != "example.com" || reopened.Defaults["output"] != "table" || reopened.Path != name {
+		t.Errorf("reopened %+v", reopened)
+	}
+}
@@ -1,21 +1,156 @@
 package main
 
 import (
+	"cf-cli/cloudflare"
+	"errors"
+	"fmt"
 	"github.com/urfave/cli/v2"
 	"net/http"
+	"net/url"
+	"os"
 	"strconv"
+	"strings"
 )
 
 type Handler struct {
 	Ready         bool
+	File          *ConfigFile
+	Profile       string
 	Configuration *SecurityConfiguration
+	Client        *cloudflare.Client
 }
 
-func (h *Handler) shouldReady() bool {
+var ErrUnready = errors.New("no credentials, run 'cf-cli setup' or set CLOUDFLARE_API_TOKEN")
+
+func (h *Handler) shouldReady() error {
 	if !h.Ready {
-		FailPrintf("Configuration unready")
+		return ErrUnready
+	}
+	if h.Configuration.Locked() {
+		if err := h.File.Unlock(h.Profile, h.Configuration); err != nil {
+			return err
+		}
+		h.Client.Credentials = h.Configuration.Credentials()
 	}
-	return h.Ready
+	return nil
+}
+
+func (h *Handler) Prepare(c *cli.Context) error {
+	if h.File == nil {
+		name, err := ConfigFilePath(c.String("config"))
+		if err != nil {
+			return err
+		}
+		file, err := OpenConfigFile(name)
+		if err != nil {
+			return err
+		}
+		h.File = file
+	}
+	if err := h.ApplyDefaults(c); err != nil {
+		return err
+	}
+	h.Profile = h.File.ProfileName(c.String("profile"))
+
+	// Credentials given by flags, or else by environment variables, replace
+	// the ones of the profile as a whole. Within each source an API token
+	// takes precedence over a key.
+	configuration := &SecurityConfiguration{}
+	if h.Configuration != nil {
+		*configuration = *h.Configuration
+	} else if profile := h.File.Profiles[h.Profile]; profile != nil {
+		*configuration = *profile
+	}
+	sources := []SecurityConfiguration{
+		{APIToken: c.String("api-token"), XAuthEmail: c.String("x-auth-email"), XAuthKey: c.String("x-auth-key")},
+		{APIToken: os.Getenv("CLOUDFLARE_API_TOKEN"), XAuthEmail: os.Getenv("CLOUDFLARE_EMAIL"), XAuthKey: os.Getenv("CLOUDFLARE_API_KEY")},
+	}
+	for _, source := range sources {
+		if source.APIToken != "" {
+			configuration.APIToken = source.APIToken
+			configuration.XAuthEmail, configuration.XAuthKey = "", ""
+		} else if source.XAuthEmail != "" && source.XAuthKey != "" {
+			configuration.XAuthEmail, configuration.XAuthKey = source.XAuthEmail, source.XAuthKey
+			configuration.APIToken = ""
+		} else {
+			continue
+		}
+		configuration.SecretBackend = ""
+		break
+	}
+	h.Configuration = configuration
+	h.Ready = configuration.HasCredentials() || configuration.Locked()
+
+	base := cloudflare.DefaultBaseURL
+	if value, ok := h.File.Defaults["api-base"]; ok {
+		base = value
+	}
+	if h.Configuration.APIBase != "" {
+		base = h.Configuration.APIBase
+	}
+	if c.IsSet("api-base") {
+		base = c.String("api-base")
+	}
+	if err := validateBaseURL(base); err != nil {
+		return err
+	}
+
+	if c.Int("retries") < 0 {
+		return errors.New("--retries must not be negative")
+	}
+
+	if c.Float64("rate-limit") < 0 {
+		return errors.New("--rate-limit must not be negative")
+	}
+	if c.Duration("timeout") < 0 {
+		return errors.New("--timeout must not be negative")
+	}
+
+	h.Client = cloudflare.NewClient(h.Configuration.Credentials())
+	h.Client.BaseURL = base
+	h.Client.HTTPClient = &http.Client{Timeout: c.Duration("timeout")}
+	if c.Bool("debug") || c.Bool("dump-curl") {
+		h.Client.HTTPClient.Transport = &cloudflare.DebugTransport{
+			Base:   http.DefaultTransport,
+			Writer: os.Stderr,
+			Trace:  c.Bool("debug"),
+			Curl:   c.Bool("dump-curl"),
+		}
+	}
+	h.Client.Limiter = nil
+	if rate := c.Float64("rate-limit"); rate > 0 {
+		h.Client.Limiter = cloudflare.NewRateLimiter(rate)
+	}
+	h.Client.Retry.MaxRetries = c.Int("retries")
+	h.Client.Retry.AllMethods = c.Bool("retry-all-methods")
+	if c.Bool("debug") {
+		h.Client.Logf = Logf
+	}
+	return nil
+}
+
+func validateBaseURL(base string) error {
+	if u, err := url.Parse(base); err != nil || u.Scheme == "" || u.Host == "" {
+		return errors.New("invalid API base URL: " + base)
+	}
+	return nil
+}
+
+// ApplyDefaults fills the flags of a command, or the global ones, that were
+// not given with the defaults stored by 'config set'. The API base and zone
+// are resolved by Prepare and zoneID instead.
+func (h *Handler) ApplyDefaults(c *cli.Context) error {
+	for _, flag := range c.Command.Flags {
+		name := flag.Names()[0]
+		value, ok := h.File.Defaults[name]
+		if !ok || name == "api-base" || name == "zone" || c.IsSet(name) {
+			continue
+		}
+		if err := c.Set(name, value); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (h *Handler) Setup(c *cli.Context) error {
@@ -23,260 +158,413 @@ func (h *Handler) Setup(c *cli.Context) error {
 		XAuthEmail: c.String("x-auth-email"),
 		XAuthKey:   c.String("x-auth-key"),
 		APIToken:   c.String("api-token"),
+		APIBase:    c.String("api-base"),
+		Zone:       c.String("zone"),
 	}
 
-	//if newConfiguration.XAuthEmail == "" || newConfiguration.XAuthKey == "" && newConfiguration.APIToken == "" {
-	//	return cli.Exit("Invalid configuration", 0)
-	//}
+	if !newConfiguration.HasCredentials() {
+		return errors.New("invalid configuration, either --api-token or both --x-auth-email and --x-auth-key are required")
+	}
 
-	return newConfiguration.Save()
-}
+	var report *SetupReport
+	if !c.Bool("skip-verify") {
+		var err error
+		if report, err = h.verify(c, newConfiguration); err != nil {
+			return err
+		}
+	}
 
-func (h *Handler) ListDNSRecords(c *cli.Context) error {
-	if !h.shouldReady() {
-		return nil
+	profile := h.Profile
+	if c.IsSet("profile") {
+		profile = c.String("profile")
+	}
+	previous, ok := h.File.Profiles[profile]
+	if ok && !c.IsSet("zone") {
+		newConfiguration.Zone = previous.Zone
+	}
+	if err := h.File.Lock(profile, c.String("secret-backend"), newConfiguration); err != nil {
+		return err
+	}
+	h.File.Profiles[profile] = newConfiguration
+	if h.File.CurrentProfile == "" {
+		h.File.CurrentProfile = profile
+	}
+	if err := h.File.Save(); err != nil {
+		return err
+	}
+	// The old secrets are only dropped once the new ones are stored and saved.
+	if ok && previous.SecretBackend != newConfiguration.SecretBackend {
+		if err := h.File.Forget(profile, previous); err != nil {
+			return errors.New("saved profile '" + profile + "' but failed to delete its previous secrets, cause: " + err.Error())
+		}
 	}
 
-	Request(
-		http.MethodGet,
-		"/zones/{zone_id}/dns_records",
-		UseSecurity(h.Configuration),
-		UsePathParameters("zone_id", c.String("zone-id")),
-		UseQueryParametersWithMap(map[string]string{
-			"comment.absent":     c.String("comment-absent"),
-			"comment.contains":   c.String("comment-contains"),
-			"comment.endswith":   c.String("comment-endswith"),
-			"comment.exact":      c.String("comment-exact"),
-			"comment.present":    c.String("comment-present"),
-			"comment.startswith": c.String("comment-startswith"),
-			"content":            c.String("content"),
-			"direction":          c.String("direction"),
-			"match":              c.String("match"),
-			"name":               c.String("name"),
-			"order":              c.String("order"),
-			"page":               strconv.FormatUint(uint64(c.Uint("page")), 10),
-			"per_page":           strconv.FormatUint(uint64(c.Uint("per-page")), 10),
-			"proxied":            strconv.FormatBool(c.Bool("proxied")),
-			"search":             c.String("search"),
-			"tag":                c.String("tag"),
-			"tag.absent":         c.String("tag-absent"),
-			"tag.contains":       c.String("tag-contains"),
-			"tag.endswith":       c.String("tag-endswith"),
-			"tag.exact":          c.String("tag-exact"),
-			"tag.present":        c.String("tag-present"),
-			"tag.startswith":     c.String("tag-startswith"),
-			"tag_match":          c.String("tag-match"),
-			"type":               c.String("type"),
-		}),
-	)
+	if report == nil {
+		return nil
+	}
+	report.Profile = profile
+	return PrintResult(report, nil)
+}
 
-	return nil
+type SetupReport struct {
+	Profile     string                        `json:"profile"`
+	Token       *cloudflare.TokenVerification `json:"token,omitempty"`
+	Permissions []string                      `json:"permissions,omitempty"`
+	User        *cloudflare.User              `json:"user,omitempty"`
 }
 
-func (h *Handler) CreateDNSRecordA(c *cli.Context) error {
-	if !h.shouldReady() {
-		return nil
+// verify checks the new credentials against the API before they are saved.
+func (h *Handler) verify(c *cli.Context, configuration *SecurityConfiguration) (*SetupReport, error) {
+	client := *h.Client
+	client.Credentials = configuration.Credentials()
+	if configuration.APIBase != "" {
+		client.BaseURL = configuration.APIBase
 	}
 
-	Request(
-		http.MethodPost,
-		"/zones/{zone_id}/dns_records",
-		UseSecurity(h.Configuration),
-		UsePathParameters("zone_id", c.String("zone-id")),
-		UseJSONBody(map[string]any{
-			"content": c.String("content"),
-			"name":    c.String("name"),
-			"proxied": c.Bool("proxied"),
-			"type":    "A",
-			"comment": c.String("comment"),
-			"tags":    c.StringSlice("tags"),
-			"ttl":     c.Uint64("ttl"),
-		}),
-	)
-	return nil
+	report := &SetupReport{}
+	if configuration.APIToken == "" {
+		user, err := client.GetUser(c.Context)
+		if err != nil {
+			return nil, fmt.Errorf("failed to verify the auth key, cause: %w", err)
+		}
+		report.User = user
+		return report, nil
+	}
+
+	verification, err := client.VerifyToken(c.Context)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify the API token, cause: %w", err)
+	}
+	if verification.Status != "active" {
+		return nil, fmt.Errorf("the API token is %s", verification.Status)
+	}
+	report.Token = verification
+
+	// Listing the permissions needs the token to be allowed to read itself,
+	// so it is reported when possible and silently skipped otherwise.
+	if token, err := client.GetToken(c.Context, verification.ID); err == nil {
+		for _, policy := range token.Policies {
+			for _, group := range policy.PermissionGroups {
+				report.Permissions = append(report.Permissions, policy.Effect+": "+group.Name)
+			}
+		}
+	}
+	return report, nil
 }
 
-func (h *Handler) CreateDNSRecordAAAA(c *cli.Context) error {
-	if !h.shouldReady() {
-		return nil
+func (h *Handler) ListDNSRecords(c *cli.Context) error {
+	if err := h.shouldReady(); err != nil {
+		return err
 	}
 
-	Request(
-		http.MethodPost,
-		"/zones/{zone_id}/dns_records",
-		UseSecurity(h.Configuration),
-		UsePathParameters("zone_id", c.String("zone-id")),
-		UseJSONBody(map[string]any{
-			"content": c.String("content"),
-			"name":    c.String("name"),
-			"proxied": c.Bool("proxied"),
-			"type":    "AAAA",
-			"comment": c.String("comment"),
-			"tags":    c.StringSlice("tags"),
-			"ttl":     c.Uint64("ttl"),
-		}),
-	)
-	return nil
-}
+	output, err := NewRecordOutput(c)
+	if err != nil {
+		return err
+	}
 
-func (h *Handler) ExportDNSRecords(c *cli.Context) error {
-	if !h.shouldReady() {
-		return nil
+	query := map[string]string{
+		"comment.absent":     c.String("comment-absent"),
+		"comment.contains":   c.String("comment-contains"),
+		"comment.endswith":   c.String("comment-endswith"),
+		"comment.exact":      c.String("comment-exact"),
+		"comment.present":    c.String("comment-present"),
+		"comment.startswith": c.String("comment-startswith"),
+		"content":            c.String("content"),
+		"direction":          c.String("direction"),
+		"match":              c.String("match"),
+		"name":               c.String("name"),
+		"order":              c.String("order"),
+		"page":               strconv.FormatUint(uint64(c.Uint("page")), 10),
+		"per_page":           strconv.FormatUint(uint64(c.Uint("per-page")), 10),
+		"search":             c.String("search"),
+		"tag":                c.String("tag"),
+		"tag.absent":         c.String("tag-absent"),
+		"tag.contains":       c.String("tag-contains"),
+		"tag.endswith":       c.String("tag-endswith"),
+		"tag.exact":          c.String("tag-exact"),
+		"tag.present":        c.String("tag-present"),
+		"tag.startswith":     c.String("tag-startswith"),
+		"tag_match":          c.String("tag-match"),
+		"type":               c.String("type"),
+	}
+	if c.IsSet("proxied") {
+		query["proxied"] = strconv.FormatBool(c.Bool("proxied"))
 	}
 
-	Request(
-		http.MethodGet,
-		"/zones/{zone_id}/dns_records/export",
-		UseSecurity(h.Configuration),
-		UsePathParameters("zone_id", c.String("zone-id")),
-	)
+	return h.inZone(c, func(zoneID string) error {
+		var records []cloudflare.DNSRecord
+		var info *cloudflare.ResultInfo
+		var err error
+		if c.Bool("all") && output.Streams() {
+			return h.Client.EachRecordPage(c.Context, zoneID, query, c.Int("max-pages"), output.Records)
+		} else if c.Bool("all") {
+			records, info, err = h.Client.ListAllRecords(c.Context, zoneID, query, c.Int("max-pages"))
+		} else {
+			records, info, err = h.Client.ListRecords(c.Context, zoneID, query)
+		}
+		if err != nil {
+			return err
+		}
+
+		return output.Records(records, info)
+	})
+}
 
-	return nil
+func (h *Handler) CreateDNSRecord(t RecordType) cli.ActionFunc {
+	return func(c *cli.Context) error {
+		if err := h.shouldReady(); err != nil {
+			return err
+		}
+
+		payload, err := t.Payload(c)
+		if err != nil {
+			return err
+		}
+
+		return h.inZone(c, func(zoneID string) error {
+			record, err := h.Client.CreateRecord(c.Context, zoneID, t.Body(c, payload))
+			if err != nil {
+				return err
+			}
+			return PrintResult(record, nil)
+		})
+	}
 }
 
-func (h *Handler) ScanDNSRecord(c *cli.Context) error {
-	if !h.shouldReady() {
-		return nil
+func (h *Handler) ExportDNSRecords(c *cli.Context) error {
+	if err := h.shouldReady(); err != nil {
+		return err
 	}
 
-	Request(
-		http.MethodPost,
-		"/zones/{zone_id}/dns_records/scan",
-		UseSecurity(h.Configuration),
-		UsePathParameters("zone_id", c.String("zone-id")),
-	)
+	return h.inZone(c, func(zoneID string) error {
+		bind, err := h.Client.ExportRecords(c.Context, zoneID)
+		if err != nil {
+			return err
+		}
 
-	return nil
+		return PrintResult(bind, nil)
+	})
 }
 
-func (h *Handler) DeleteDNSRecord(c *cli.Context) error {
-	if !h.shouldReady() {
-		return nil
+func (h *Handler) ImportDNSRecords(c *cli.Context) error {
+	if err := h.shouldReady(); err != nil {
+		return err
 	}
 
-	Request(
-		http.MethodDelete,
-		"/zones/{zone_id}/dns_records/{dns_record_id}",
-		UseSecurity(h.Configuration),
-		UsePathParameters("zone_id", c.String("zone-id")),
-		UsePathParameters("dns_record_id", c.String("record-id")),
-	)
+	output, err := NewOutput(c)
+	if err != nil {
+		return err
+	}
 
-	return nil
+	if c.String("file") == "" {
+		return errors.New("--file is required, - for stdin")
+	}
+	bind, err := readInput(c.String("file"))
+	if err != nil {
+		return errors.New("failed to read zone file, cause: " + err.Error())
+	}
+
+	return h.inZone(c, func(zoneID string) error {
+		result, err := h.Client.ImportRecords(c.Context, zoneID, bind, c.Bool("proxied"))
+		if err != nil {
+			return err
+		}
+
+		return output.Value(result)
+	})
 }
 
-func (h *Handler) DNSRecordDetails(c *cli.Context) error {
-	if !h.shouldReady() {
-		return nil
+func (h *Handler) ScanDNSRecord(c *cli.Context) error {
+	if err := h.shouldReady(); err != nil {
+		return err
+	}
+
+	output, err := NewOutput(c)
+	if err != nil {
+		return err
 	}
 
-	Request(
-		http.MethodGet,
-		"/zones/{zone_id}/dns_records/{dns_record_id}",
-		UseSecurity(h.Configuration),
-		UsePathParameters("zone_id", c.String("zone-id")),
-		UsePathParameters("dns_record_id", c.String("record-id")),
-	)
+	return h.inZone(c, func(zoneID string) error {
+		result, err := h.Client.ScanRecords(c.Context, zoneID)
+		if err != nil {
+			return err
+		}
 
-	return nil
+		return output.Value(result)
+	})
 }
 
-func (h *Handler) UpdateDNSRecordA(c *cli.Context) error {
-	if !h.shouldReady() {
-		return nil
+// selectRecords finds the records a command acts on, either the one given by
+// --record-id or those matching name, type and content. Records selected by
+// --record-id only carry their identifier.
+func (h *Handler) selectRecords(c *cli.Context, zoneID, name, recordType, content string) ([]cloudflare.DNSRecord, error) {
+	if id := c.String("record-id"); id != "" {
+		return []cloudflare.DNSRecord{{ID: id}}, nil
+	}
+	if name == "" {
+		return nil, errors.New("either --record-id or a record name is required")
 	}
 
-	Request(
-		http.MethodPatch,
-		"/zones/{zone_id}/dns_records/{dns_record_id}",
-		UseSecurity(h.Configuration),
-		UsePathParameters("zone_id", c.String("zone-id")),
-		UsePathParameters("dns_record_id", c.String("record-id")),
-		UseJSONBody(map[string]any{
-			"content": c.String("content"),
-			"name":    c.String("name"),
-			"proxied": c.Bool("proxied"),
-			"type":    "A",
-			"comment": c.String("comment"),
-			"tags":    c.StringSlice("tags"),
-			"ttl":     c.Uint64("ttl"),
-		}),
-	)
+	records, _, err := h.Client.ListAllRecords(c.Context, zoneID, map[string]string{
+		"name":     name,
+		"type":     recordType,
+		"content":  content,
+		"match":    "all",
+		"per_page": "5000",
+	}, 0)
+	if err != nil {
+		return nil, err
+	}
 
-	return nil
+	description := strings.TrimSpace(recordType + " record '" + name + "'")
+	if content != "" {
+		description += " with content '" + content + "'"
+	}
+	switch {
+	case len(records) == 0:
+		return nil, fmt.Errorf("%s: %w", description, cloudflare.ErrNotFound)
+	case len(records) > 1 && !c.Bool("all-matches"):
+		ids := make([]string, len(records))
+		for i, record := range records {
+			ids[i] = record.ID
+		}
+		return nil, fmt.Errorf("%s matches %d records (%s), narrow the selection or pass --all-matches",
+			description, len(records), strings.Join(ids, ", "))
+	}
+	return records, nil
 }
 
-func (h *Handler) UpdateDNSRecordAAAA(c *cli.Context) error {
-	if !h.shouldReady() {
-		return nil
+// stoppedError reports which of the total selected records were already
+// done when err stopped the batch.
+func stoppedError(verb string, done []string, total int, err error) error {
+	if total == 1 {
+		return err
+	}
+	if len(done) == 0 {
+		return fmt.Errorf("stopped after %s 0 of %d records: %w", verb, total, err)
 	}
+	return fmt.Errorf("stopped after %s %d of %d records (%s): %w", verb, len(done), total, strings.Join(done, ", "), err)
+}
 
-	Request(
-		http.MethodPatch,
-		"/zones/{zone_id}/dns_records/{dns_record_id}",
-		UseSecurity(h.Configuration),
-		UsePathParameters("zone_id", c.String("zone-id")),
-		UsePathParameters("dns_record_id", c.String("record-id")),
-		UseJSONBody(map[string]any{
-			"content": c.String("content"),
-			"name":    c.String("name"),
-			"proxied": c.Bool("proxied"),
-			"type":    "AAAA",
-			"comment": c.String("comment"),
-			"tags":    c.StringSlice("tags"),
-			"ttl":     c.Uint64("ttl"),
-		}),
-	)
+func (h *Handler) DeleteDNSRecord(c *cli.Context) error {
+	if err := h.shouldReady(); err != nil {
+		return err
+	}
 
-	return nil
+	return h.inZone(c, func(zoneID string) error {
+		records, err := h.selectRecords(c, zoneID, c.String("name"), c.String("type"), c.String("content"))
+		if err != nil {
+			return err
+		}
+
+		deleted := make([]map[string]string, 0, len(records))
+		done := make([]string, 0, len(records))
+		for _, record := range records {
+			if err := c.Context.Err(); err != nil {
+				return stoppedError("deleting", done, len(records), err)
+			}
+			id, err := h.Client.DeleteRecord(c.Context, zoneID, record.ID)
+			if err != nil {
+				return stoppedError("deleting", done, len(records), err)
+			}
+			deleted = append(deleted, map[string]string{"id": id})
+			done = append(done, record.ID)
+		}
+
+		if len(deleted) == 1 {
+			return PrintResult(deleted[0], nil)
+		}
+		return PrintResult(deleted, nil)
+	})
 }
 
-func (h *Handler) OverwriteDNSRecordA(c *cli.Context) error {
-	if !h.shouldReady() {
-		return nil
+func (h *Handler) DNSRecordDetails(c *cli.Context) error {
+	if err := h.shouldReady(); err != nil {
+		return err
 	}
 
-	Request(
-		http.MethodPatch,
-		"/zones/{zone_id}/dns_records/{dns_record_id}",
-		UseSecurity(h.Configuration),
-		UsePathParameters("zone_id", c.String("zone-id")),
-		UsePathParameters("dns_record_id", c.String("record-id")),
-		UseJSONBody(map[string]any{
-			"content": c.String("content"),
-			"name":    c.String("name"),
-			"proxied": c.Bool("proxied"),
-			"type":    "A",
-			"comment": c.String("comment"),
-			"tags":    c.StringSlice("tags"),
-			"ttl":     c.Uint64("ttl"),
-		}),
-	)
+	output, err := NewRecordOutput(c)
+	if err != nil {
+		return err
+	}
 
-	return nil
+	return h.inZone(c, func(zoneID string) error {
+		if id := c.String("record-id"); id != "" {
+			record, err := h.Client.GetRecord(c.Context, zoneID, id)
+			if err != nil {
+				return err
+			}
+			return output.Record(record)
+		}
+
+		records, err := h.selectRecords(c, zoneID, c.String("name"), c.String("type"), c.String("content"))
+		if err != nil {
+			return err
+		}
+		if len(records) == 1 {
+			return output.Record(&records[0])
+		}
+		return output.Records(records, nil)
+	})
 }
 
-func (h *Handler) OverwriteDNSRecordAAAA(c *cli.Context) error {
-	if !h.shouldReady() {
-		return nil
-	}
+func (h *Handler) UpdateDNSRecord(t RecordType) cli.ActionFunc {
+	return h.writeDNSRecords(t, func(c *cli.Context, zoneID, recordID string, payload map[string]any) (*cloudflare.DNSRecord, error) {
+		return h.Client.UpdateRecord(c.Context, zoneID, recordID, t.Patch(c, payload))
+	})
+}
 
-	Request(
-		http.MethodPatch,
-		"/zones/{zone_id}/dns_records/{dns_record_id}",
-		UseSecurity(h.Configuration),
-		UsePathParameters("zone_id", c.String("zone-id")),
-		UsePathParameters("dns_record_id", c.String("record-id")),
-		UseJSONBody(map[string]any{
-			"content": c.String("content"),
-			"name":    c.String("name"),
-			"proxied": c.Bool("proxied"),
-			"type":    "AAAA",
-			"comment": c.String("comment"),
-			"tags":    c.StringSlice("tags"),
-			"ttl":     c.Uint64("ttl"),
-		}),
-	)
+func (h *Handler) OverwriteDNSRecord(t RecordType) cli.ActionFunc {
+	return h.writeDNSRecords(t, func(c *cli.Context, zoneID, recordID string, payload map[string]any) (*cloudflare.DNSRecord, error) {
+		return h.Client.OverwriteRecord(c.Context, zoneID, recordID, t.Body(c, payload))
+	})
+}
 
-	return nil
+// writeDNSRecords applies write to the records selected by --record-id or by
+// --match-name, which falls back to --name and then the name in --from-file,
+// and --match-content.
+func (h *Handler) writeDNSRecords(t RecordType, write func(c *cli.Context, zoneID, recordID string, payload map[string]any) (*cloudflare.DNSRecord, error)) cli.ActionFunc {
+	return func(c *cli.Context) error {
+		if err := h.shouldReady(); err != nil {
+			return err
+		}
+
+		payload, err := t.Payload(c)
+		if err != nil {
+			return err
+		}
+
+		name := c.String("match-name")
+		if name == "" {
+			name = c.String("name")
+		}
+		if name == "" {
+			name, _ = payload["name"].(string)
+		}
+		return h.inZone(c, func(zoneID string) error {
+			selected, err := h.selectRecords(c, zoneID, name, t.Name, c.String("match-content"))
+			if err != nil {
+				return err
+			}
+
+			records := make([]*cloudflare.DNSRecord, 0, len(selected))
+			done := make([]string, 0, len(selected))
+			for _, record := range selected {
+				if err := c.Context.Err(); err != nil {
+					return stoppedError("writing", done, len(selected), err)
+				}
+				written, err := write(c, zoneID, record.ID, payload)
+				if err != nil {
+					return stoppedError("writing", done, len(selected), err)
+				}
+				records = append(records, written)
+				done = append(done, record.ID)
+			}
+
+			if len(records) == 1 {
+				return PrintResult(records[0], nil)
+			}
+			return PrintResult(records, nil)
+		})
+	}
 }
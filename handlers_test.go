@@ -0,0 +1,743 @@
+package main
+
+import (
+	"bytes"
+	"cf-cli/cloudflare"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/urfave/cli/v2"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordedRequest struct {
+	Method string
+	Path   string
+	Query  url.Values
+	Header http.Header
+	Body   map[string]any
+	Raw    []byte
+}
+
+// standIn is a local stand-in for the Cloudflare API that records every
+// request it receives.
+type standIn struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []recordedRequest
+}
+
+// newStandIn answers every request with an empty result, or with respond when
+// it is not nil.
+func newStandIn(t *testing.T, respond http.HandlerFunc) *standIn {
+	t.Helper()
+	s := &standIn{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		recorded := recordedRequest{Method: r.Method, Path: r.URL.Path, Query: r.URL.Query(), Header: r.Header.Clone(), Raw: raw}
+		json.Unmarshal(raw, &recorded.Body)
+		s.mu.Lock()
+		s.requests = append(s.requests, recorded)
+		s.mu.Unlock()
+
+		r.Body = io.NopCloser(bytes.NewReader(raw))
+		if respond != nil {
+			respond(w, r)
+			return
+		}
+		writeResult(w, map[string]any{}, nil)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *standIn) Requests() []recordedRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]recordedRequest(nil), s.requests...)
+}
+
+func writeResult(w http.ResponseWriter, result any, info *cloudflare.ResultInfo) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"success": true, "errors": []any{}, "messages": []any{}, "result": result, "result_info": info})
+}
+
+func writeError(w http.ResponseWriter, status, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{"success": false, "errors": []any{map[string]any{"code": code, "message": message}}, "messages": []any{}, "result": nil})
+}
+
+// isolate points every file the CLI reads or writes, and its credential
+// variables, away from the user running the tests.
+func isolate(t *testing.T) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, "config"))
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(home, "cache"))
+	for _, name := range []string{"CLOUDFLARE_API_TOKEN", "CLOUDFLARE_EMAIL", "CLOUDFLARE_API_KEY", "CF_PROFILE", "CF_API_BASE", "CF_CLI_CONFIG"} {
+		t.Setenv(name, "")
+	}
+}
+
+// run runs the CLI against the stand-in and returns what it printed on
+// stdout. A nil handler uses a profile with an API token.
+func (s *standIn) run(t *testing.T, handler *Handler, args ...string) (string, error) {
+	t.Helper()
+	return s.runContext(t, context.Background(), handler, args...)
+}
+
+// runContext is like run, with ctx standing for the signals the CLI stops on.
+func (s *standIn) runContext(t *testing.T, ctx context.Context, handler *Handler, args ...string) (string, error) {
+	t.Helper()
+	if handler == nil {
+		handler = &Handler{Configuration: &SecurityConfiguration{APIToken: "token"}}
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+	printed := make(chan string)
+	go func() {
+		raw, _ := io.ReadAll(r)
+		printed <- string(raw)
+	}()
+
+	args = append([]string{"cf-cli", "--api-base", s.URL + "/client/v4", "--rate-limit", "0"}, args...)
+	err = NewApp(handler).RunContext(ctx, args)
+	w.Close()
+	return <-printed, err
+}
+
+// stdinFrom makes input the standard input of the test.
+func stdinFrom(t *testing.T, input string) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		w.WriteString(input)
+		w.Close()
+	}()
+	stdin := os.Stdin
+	os.Stdin = r
+	t.Cleanup(func() { os.Stdin = stdin })
+}
+
+func runAgainstStandIn(t *testing.T, args ...string) recordedRequest {
+	t.Helper()
+	isolate(t)
+	s := newStandIn(t, nil)
+	if _, err := s.run(t, nil, args...); err != nil {
+		t.Fatalf("failed to run %v: %s", args, err)
+	}
+	requests := s.Requests()
+	if len(requests) != 1 {
+		t.Fatalf("%v sent %d requests, want 1", args, len(requests))
+	}
+	return requests[0]
+}
+
+func TestOverwriteDiffersFromUpdate(t *testing.T) {
+	args := []string{"--zone-id", "zone", "--record-id", "record", "--name", "www.example.com", "--content", "192.0.2.1"}
+
+	update := runAgainstStandIn(t, append([]string{"update", "a"}, args...)...)
+	overwrite := runAgainstStandIn(t, append([]string{"overwrite", "a"}, args...)...)
+
+	if update.Method != http.MethodPatch {
+		t.Errorf("update method = %s, want %s", update.Method, http.MethodPatch)
+	}
+	if overwrite.Method != http.MethodPut {
+		t.Errorf("overwrite method = %s, want %s", overwrite.Method, http.MethodPut)
+	}
+	for _, r := range []recordedRequest{update, overwrite} {
+		if r.Path != "/client/v4/zones/zone/dns_records/record" {
+			t.Errorf("%s path = %s", r.Method, r.Path)
+		}
+	}
+}
+
+func TestOverwriteResetsOmittedFields(t *testing.T) {
+	r := runAgainstStandIn(t, "overwrite", "a",
+		"--zone-id", "zone", "--record-id", "record", "--name", "www.example.com", "--content", "192.0.2.1")
+
+	if comment, ok := r.Body["comment"]; !ok || comment != "" {
+		t.Errorf("comment = %v, want empty string", comment)
+	}
+	if tags, ok := r.Body["tags"].([]any); !ok || len(tags) != 0 {
+		t.Errorf("tags = %v, want empty list", r.Body["tags"])
+	}
+	if r.Body["type"] != "A" || r.Body["content"] != "192.0.2.1" || r.Body["name"] != "www.example.com" {
+		t.Errorf("unexpected body %v", r.Body)
+	}
+}
+
+func TestUpdateOnlySendsSetFlags(t *testing.T) {
+	r := runAgainstStandIn(t, "update", "a", "--zone-id", "zone", "--record-id", "record", "--ttl", "300", "--clear-comment")
+
+	want := map[string]any{"ttl": float64(300), "comment": ""}
+	if len(r.Body) != len(want) {
+		t.Fatalf("body = %v, want %v", r.Body, want)
+	}
+	for key, value := range want {
+		if r.Body[key] != value {
+			t.Errorf("%s = %v, want %v", key, r.Body[key], value)
+		}
+	}
+}
+
+func TestUpdateSendsTypeOnlyFromFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "record.json")
+	if err := os.WriteFile(name, []byte(`{"type":"A","content":"192.0.2.1"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	r := runAgainstStandIn(t, "update", "a", "--zone-id", "zone", "--record-id", "record", "--content", "192.0.2.2")
+	if _, ok := r.Body["type"]; ok {
+		t.Errorf("type sent without being given: %v", r.Body)
+	}
+	r = runAgainstStandIn(t, "update", "a", "--zone-id", "zone", "--record-id", "record", "--from-file", name)
+	if r.Body["type"] != "A" {
+		t.Errorf("type = %v, want A from the file", r.Body["type"])
+	}
+}
+
+func TestFlagsOverrideFromFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "record.yaml")
+	payload := "id: old\nname: www.example.com\ncontent: 192.0.2.1\nttl: 300\nsettings:\n  ipv4_only: true\n"
+	if err := os.WriteFile(name, []byte(payload), 0600); err != nil {
+		t.Fatal(err)
+	}
+	r := runAgainstStandIn(t, "update", "a", "--zone-id", "zone", "--record-id", "record", "--from-file", name, "--ttl", "60")
+
+	if r.Body["ttl"] != float64(60) || r.Body["content"] != "192.0.2.1" || r.Body["name"] != "www.example.com" {
+		t.Errorf("unexpected body %v", r.Body)
+	}
+	if _, ok := r.Body["id"]; ok {
+		t.Errorf("read-only id was sent")
+	}
+	if settings, ok := r.Body["settings"].(map[string]any); !ok || settings["ipv4_only"] != true {
+		t.Errorf("settings = %v, want them sent as given", r.Body["settings"])
+	}
+}
+
+func TestCreateAndOverwriteFromFile(t *testing.T) {
+	record := `{"name":"www.example.com","type":"A","content":"192.0.2.1","ttl":300}`
+	envelope := `{"success":true,"errors":[],"messages":[],"result":{"id":"old","name":"www.example.com","type":"A","content":"192.0.2.1","ttl":300}}`
+	tests := []struct {
+		name   string
+		args   []string
+		stdin  string
+		method string
+		path   string
+	}{
+		{name: "create", args: []string{"create", "a"}, method: http.MethodPost, path: "/client/v4/zones/zone/dns_records"},
+		{name: "overwrite", args: []string{"overwrite", "a", "--record-id", "record"}, method: http.MethodPut, path: "/client/v4/zones/zone/dns_records/record"},
+		{name: "stdin", args: []string{"create", "a"}, stdin: record, method: http.MethodPost, path: "/client/v4/zones/zone/dns_records"},
+		{name: "details output", args: []string{"create", "a"}, stdin: envelope, method: http.MethodPost, path: "/client/v4/zones/zone/dns_records"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			name := "-"
+			if test.stdin != "" {
+				stdinFrom(t, test.stdin)
+			} else {
+				name = filepath.Join(t.TempDir(), "record.json")
+				if err := os.WriteFile(name, []byte(record), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			r := runAgainstStandIn(t, append(test.args, "--zone-id", "zone", "--from-file", name)...)
+
+			if r.Method != test.method || r.Path != test.path {
+				t.Errorf("sent %s %s, want %s %s", r.Method, r.Path, test.method, test.path)
+			}
+			want := map[string]any{"name": "www.example.com", "type": "A", "content": "192.0.2.1", "ttl": float64(300)}
+			for key, value := range want {
+				if r.Body[key] != value {
+					t.Errorf("%s = %v, want %v", key, r.Body[key], value)
+				}
+			}
+			for _, key := range []string{"id", "success", "result"} {
+				if _, ok := r.Body[key]; ok {
+					t.Errorf("%s was sent: %v", key, r.Body)
+				}
+			}
+		})
+	}
+}
+
+func TestInvalidRecordFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		payload string
+		want    string
+	}{
+		{name: "type mismatch", args: []string{"create", "a"}, payload: `{"name":"www","type":"CNAME","content":"example.com"}`,
+			want: "the record file is of type CNAME, not A"},
+		{name: "wrong field type", args: []string{"create", "a"}, payload: `{"name":"www","ttl":"long"}`,
+			want: "invalid record file"},
+		{name: "wrong data type", args: []string{"create", "srv"}, payload: "name: _sip._tcp\ndata:\n  port: x\n",
+			want: "invalid record file, data.port has the wrong type"},
+		{name: "list", args: []string{"create", "a"}, payload: `[{"name":"www"}]`,
+			want: "the record file must contain a single object"},
+		{name: "no record fields", args: []string{"create", "a"}, payload: `{"success":false,"errors":[]}`,
+			want: "the record file has none of the record fields"},
+		{name: "update", args: []string{"update", "a", "--record-id", "record"}, payload: `{"type":"AAAA"}`,
+			want: "the record file is of type AAAA, not A"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			isolate(t)
+			stdinFrom(t, test.payload)
+			s := newStandIn(t, nil)
+			_, err := s.run(t, nil, append(test.args, "--zone-id", "zone", "--from-file", "-")...)
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("err = %v, want %q", err, test.want)
+			}
+			if requests := s.Requests(); len(requests) != 0 {
+				t.Errorf("sent %d requests for an invalid record file", len(requests))
+			}
+		})
+	}
+}
+
+func TestCredentialPrecedence(t *testing.T) {
+	tests := []struct {
+		name  string
+		env   map[string]string
+		args  []string
+		token string
+		key   string
+	}{
+		{name: "profile", token: "token"},
+		{name: "env token over profile", env: map[string]string{"CLOUDFLARE_API_TOKEN": "envtok"}, token: "envtok"},
+		{name: "env key over profile", env: map[string]string{"CLOUDFLARE_EMAIL": "env@example.com", "CLOUDFLARE_API_KEY": "envkey"}, key: "envkey"},
+		{name: "flag key over env token", env: map[string]string{"CLOUDFLARE_API_TOKEN": "envtok"},
+			args: []string{"--x-auth-email", "me@example.com", "--x-auth-key", "KEY"}, key: "KEY"},
+		{name: "flag token over env key", env: map[string]string{"CLOUDFLARE_EMAIL": "env@example.com", "CLOUDFLARE_API_KEY": "envkey"},
+			args: []string{"--api-token", "flagtok"}, token: "flagtok"},
+		{name: "incomplete flags fall back to env", env: map[string]string{"CLOUDFLARE_API_TOKEN": "envtok"},
+			args: []string{"--x-auth-email", "me@example.com"}, token: "envtok"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			isolate(t)
+			for name, value := range test.env {
+				t.Setenv(name, value)
+			}
+			s := newStandIn(t, nil)
+			args := append(test.args, "details", "--zone-id", "zone", "--record-id", "record")
+			if _, err := s.run(t, nil, args...); err != nil {
+				t.Fatalf("failed to run %v: %s", args, err)
+			}
+
+			header := s.Requests()[0].Header
+			wantAuthorization := ""
+			if test.token != "" {
+				wantAuthorization = "Bearer " + test.token
+			}
+			if got := header.Get("Authorization"); got != wantAuthorization {
+				t.Errorf("Authorization = %q, want %q", got, wantAuthorization)
+			}
+			if got := header.Get("X-Auth-Key"); got != test.key {
+				t.Errorf("X-Auth-Key = %q, want %q", got, test.key)
+			}
+		})
+	}
+}
+
+func TestVersionFlag(t *testing.T) {
+	isolate(t)
+	s := newStandIn(t, nil)
+	versionFlag := cli.VersionFlag
+
+	for _, args := range [][]string{{"--version"}, {"-v", "--version"}} {
+		stdout, err := s.run(t, nil, args...)
+		if err != nil || stdout != "cf-cli version 0.0.1\n" {
+			t.Errorf("%v printed %q, %v", args, stdout, err)
+		}
+	}
+	if cli.VersionFlag != versionFlag {
+		t.Errorf("NewApp replaced cli.VersionFlag")
+	}
+}
+
+// recordsStandIn lists the records named by matches and fails writes and
+// deletes of the record failing.
+func recordsStandIn(t *testing.T, matches map[string][]string, failing string) *standIn {
+	return newStandIn(t, func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Path, "/client/v4/zones/zone/dns_records/")
+		switch {
+		case r.Method == http.MethodGet && id == r.URL.Path:
+			records := []cloudflare.DNSRecord{}
+			for _, id := range matches[r.URL.Query().Get("name")] {
+				records = append(records, cloudflare.DNSRecord{ID: id, Name: r.URL.Query().Get("name"), Type: "A"})
+			}
+			writeResult(w, records, &cloudflare.ResultInfo{Page: 1, TotalPages: 1, Count: len(records), TotalCount: len(records)})
+		case id == failing:
+			writeError(w, http.StatusBadRequest, 9005, "Content for A record is invalid")
+		default:
+			writeResult(w, cloudflare.DNSRecord{ID: id, Type: "A"}, nil)
+		}
+	})
+}
+
+func changedRecords(s *standIn) []string {
+	var ids []string
+	for _, r := range s.Requests() {
+		if r.Method != http.MethodGet {
+			ids = append(ids, r.Method+" "+strings.TrimPrefix(r.Path, "/client/v4/zones/zone/dns_records/"))
+		}
+	}
+	return ids
+}
+
+func TestDeleteSelection(t *testing.T) {
+	matches := map[string][]string{"one.example.com": {"r1"}, "many.example.com": {"r1", "r2", "r3"}}
+	tests := []struct {
+		name    string
+		args    []string
+		deleted []string
+		err     string
+	}{
+		{"zero", []string{"--name", "none.example.com"}, nil, "record 'none.example.com': not found"},
+		{"one", []string{"--name", "one.example.com"}, []string{"DELETE r1"}, ""},
+		{"many", []string{"--name", "many.example.com"}, nil, "matches 3 records (r1, r2, r3)"},
+		{"all matches", []string{"--name", "many.example.com", "--all-matches"}, []string{"DELETE r1", "DELETE r2", "DELETE r3"}, ""},
+		{"record id", []string{"--record-id", "r9"}, []string{"DELETE r9"}, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			isolate(t)
+			s := recordsStandIn(t, matches, "")
+
+			_, err := s.run(t, nil, append([]string{"delete", "--zone-id", "zone"}, test.args...)...)
+			if test.err == "" && err != nil || test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+				t.Errorf("err = %v, want %q", err, test.err)
+			}
+			if deleted := changedRecords(s); strings.Join(deleted, ",") != strings.Join(test.deleted, ",") {
+				t.Errorf("deleted %v, want %v", deleted, test.deleted)
+			}
+		})
+	}
+}
+
+func TestBatchReportsCompletedRecords(t *testing.T) {
+	matches := map[string][]string{"many.example.com": {"r1", "r2", "r3"}, "one.example.com": {"r2"}}
+	tests := []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"delete", "--zone-id", "zone", "--name", "many.example.com", "--all-matches"}, "stopped after deleting 1 of 3 records (r1): 9005: Content for A record is invalid"},
+		{[]string{"update", "a", "--zone-id", "zone", "--match-name", "many.example.com", "--all-matches", "--content", "192.0.2.1"}, "stopped after writing 1 of 3 records (r1): 9005: Content for A record is invalid"},
+		{[]string{"delete", "--zone-id", "zone", "--name", "one.example.com"}, "9005: Content for A record is invalid"},
+	}
+	for _, test := range tests {
+		isolate(t)
+		s := recordsStandIn(t, matches, "r2")
+
+		_, err := s.run(t, nil, test.args...)
+		if err == nil || err.Error() != test.err {
+			t.Errorf("%v: err = %v, want %q", test.args, err, test.err)
+		}
+		if ExitCode(err) != ExitValidation {
+			t.Errorf("%v: exit code = %d, want %d", test.args, ExitCode(err), ExitValidation)
+		}
+		if changed := changedRecords(s); len(changed) == 0 || !strings.HasSuffix(changed[len(changed)-1], " r2") {
+			t.Errorf("%v: changed %v, want to stop at r2", test.args, changed)
+		}
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	isolate(t)
+	s := newStandIn(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	})
+
+	start := time.Now()
+	_, err := s.run(t, nil, "--timeout", "50ms", "--retries", "0", "details", "--zone-id", "zone", "--record-id", "record")
+	var networkErr *cloudflare.NetworkError
+	if !errors.As(err, &networkErr) || ExitCode(err) != ExitNetwork {
+		t.Errorf("err = %v (exit code %d), want a network error", err, ExitCode(err))
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("stalled request took %s with a 50ms timeout", elapsed)
+	}
+}
+
+func TestInterruptStopsBatch(t *testing.T) {
+	tests := []struct {
+		args    []string
+		err     string
+		changed []string
+	}{
+		{[]string{"delete", "--zone-id", "zone", "--name", "many.example.com", "--all-matches"},
+			"stopped after deleting 1 of 3 records (r1): ", []string{"DELETE r1", "DELETE r2"}},
+		{[]string{"update", "a", "--zone-id", "zone", "--match-name", "many.example.com", "--all-matches", "--content", "192.0.2.1"},
+			"stopped after writing 1 of 3 records (r1): ", []string{"PATCH r1", "PATCH r2"}},
+	}
+	for _, test := range tests {
+		isolate(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		records := recordsStandIn(t, map[string][]string{"many.example.com": {"r1", "r2", "r3"}}, "")
+		// The interrupt arrives while r2 is being written.
+		s := newStandIn(t, func(w http.ResponseWriter, r *http.Request) {
+			if strings.HasSuffix(r.URL.Path, "/r2") {
+				cancel()
+				<-r.Context().Done()
+				return
+			}
+			records.Config.Handler.ServeHTTP(w, r)
+		})
+
+		_, err := s.runContext(t, ctx, nil, test.args...)
+		cancel()
+		if !errors.Is(err, context.Canceled) || !strings.HasPrefix(err.Error(), test.err) {
+			t.Errorf("%v: err = %v, want %q and the cancellation", test.args, err, test.err)
+		}
+		if ExitCode(err) != ExitInterrupted {
+			t.Errorf("%v: exit code = %d, want %d", test.args, ExitCode(err), ExitInterrupted)
+		}
+		if changed := changedRecords(s); strings.Join(changed, ",") != strings.Join(test.changed, ",") {
+			t.Errorf("%v: changed %v, want %v", test.args, changed, test.changed)
+		}
+	}
+}
+
+func TestListAllPages(t *testing.T) {
+	isolate(t)
+	s := newStandIn(t, func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		records := []cloudflare.DNSRecord{{ID: strconv.Itoa(page) + "a"}, {ID: strconv.Itoa(page) + "b"}}
+		writeResult(w, records, &cloudflare.ResultInfo{Page: page, PerPage: 2, Count: 2, TotalCount: 8, TotalPages: 4})
+	})
+
+	stdout, err := s.run(t, nil, "list", "--zone-id", "zone", "--all", "--page", "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var merged struct {
+		Result     []cloudflare.DNSRecord
+		ResultInfo cloudflare.ResultInfo `json:"result_info"`
+	}
+	json.Unmarshal([]byte(stdout), &merged)
+	var ids []string
+	for _, record := range merged.Result {
+		ids = append(ids, record.ID)
+	}
+	if strings.Join(ids, " ") != "2a 2b 3a 3b 4a 4b" || merged.ResultInfo.Count != 6 {
+		t.Errorf("merged pages printed %s", stdout)
+	}
+
+	// ndjson prints each page as it arrives.
+	stdout, err = s.run(t, nil, "list", "--zone-id", "zone", "--all", "--page", "3", "--output", "ndjson", "--fields", "id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\"id\":\"3a\"}\n{\"id\":\"3b\"}\n{\"id\":\"4a\"}\n{\"id\":\"4b\"}\n"; stdout != want {
+		t.Errorf("streamed pages printed %q, want %q", stdout, want)
+	}
+
+	_, err = s.run(t, nil, "list", "--zone-id", "zone", "--all", "--max-pages", "2")
+	if err == nil || !strings.Contains(err.Error(), "stopped after 2 pages") {
+		t.Errorf("page limit: err = %v", err)
+	}
+}
+
+func verifyStandIn(t *testing.T, status string, readable bool) *standIn {
+	return newStandIn(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/client/v4/user/tokens/verify":
+			writeResult(w, cloudflare.TokenVerification{ID: "token-id", Status: status}, nil)
+		case "/client/v4/user/tokens/token-id":
+			if !readable {
+				writeError(w, http.StatusForbidden, 9109, "Unauthorized to access requested resource")
+				return
+			}
+			writeResult(w, cloudflare.Token{ID: "token-id", Status: status, Policies: []cloudflare.TokenPolicy{
+				{Effect: "allow", PermissionGroups: []cloudflare.PermissionGroup{{Name: "DNS Write"}, {Name: "Zone Read"}}},
+			}}, nil)
+		case "/client/v4/user":
+			if r.Header.Get("X-Auth-Key") != "key" {
+				writeError(w, http.StatusForbidden, 9103, "Unknown X-Auth-Key or X-Auth-Email")
+				return
+			}
+			writeResult(w, cloudflare.User{ID: "user-id", Email: r.Header.Get("X-Auth-Email")}, nil)
+		default:
+			writeError(w, http.StatusNotFound, 7000, "No route for that URI")
+		}
+	})
+}
+
+func TestSetupVerifiesCredentials(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   string
+		readable bool
+		args     []string
+		paths    []string
+		report   *SetupReport
+		err      string
+	}{
+		{
+			name: "active token", status: "active", readable: true,
+			args:   []string{"--api-token", "token"},
+			paths:  []string{"/client/v4/user/tokens/verify", "/client/v4/user/tokens/token-id"},
+			report: &SetupReport{Profile: DefaultProfile, Token: &cloudflare.TokenVerification{ID: "token-id", Status: "active"}, Permissions: []string{"allow: DNS Write", "allow: Zone Read"}},
+		},
+		{
+			name: "token that cannot read itself", status: "active",
+			args:   []string{"--api-token", "token"},
+			paths:  []string{"/client/v4/user/tokens/verify", "/client/v4/user/tokens/token-id"},
+			report: &SetupReport{Profile: DefaultProfile, Token: &cloudflare.TokenVerification{ID: "token-id", Status: "active"}},
+		},
+		{
+			name: "inactive token", status: "disabled",
+			args:  []string{"--api-token", "token"},
+			paths: []string{"/client/v4/user/tokens/verify"},
+			err:   "the API token is disabled",
+		},
+		{
+			name:   "auth key",
+			args:   []string{"--x-auth-email", "someone@example.com", "--x-auth-key", "key"},
+			paths:  []string{"/client/v4/user"},
+			report: &SetupReport{Profile: DefaultProfile, User: &cloudflare.User{ID: "user-id", Email: "someone@example.com"}},
+		},
+		{
+			name:  "wrong auth key",
+			args:  []string{"--x-auth-email", "someone@example.com", "--x-auth-key", "wrong"},
+			paths: []string{"/client/v4/user"},
+			err:   "failed to verify the auth key, cause: 9103: Unknown X-Auth-Key or X-Auth-Email",
+		},
+		{
+			name: "missing credentials",
+			args: []string{"--x-auth-email", "someone@example.com"},
+			err:  "invalid configuration, either --api-token or both --x-auth-email and --x-auth-key are required",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			isolate(t)
+			s := verifyStandIn(t, test.status, test.readable)
+			handler := &Handler{}
+
+			stdout, err := s.run(t, handler, append([]string{"setup"}, test.args...)...)
+			var paths []string
+			for _, r := range s.Requests() {
+				paths = append(paths, r.Path)
+			}
+			if strings.Join(paths, " ") != strings.Join(test.paths, " ") {
+				t.Errorf("requested %v, want %v", paths, test.paths)
+			}
+			if test.err != "" {
+				if err == nil || err.Error() != test.err {
+					t.Errorf("err = %v, want %q", err, test.err)
+				}
+				if _, saved := handler.File.Profiles[DefaultProfile]; saved {
+					t.Errorf("profile saved although verification failed")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var printed struct{ Result *SetupReport }
+			if err = json.Unmarshal([]byte(stdout), &printed); err != nil {
+				t.Fatalf("printed %q: %s", stdout, err)
+			}
+			got, _ := json.Marshal(printed.Result)
+			want, _ := json.Marshal(test.report)
+			if string(got) != string(want) {
+				t.Errorf("report = %s, want %s", got, want)
+			}
+			if _, saved := handler.File.Profiles[DefaultProfile]; !saved {
+				t.Errorf("profile not saved")
+			}
+		})
+	}
+}
+
+func TestImportSendsZoneFile(t *testing.T) {
+	const zone = "www.example.com.\t300\tIN\tA\t192.0.2.1\nexample.com.\t300\tIN\tMX\t10 mail.example.com.\n"
+	name := filepath.Join(t.TempDir(), "example.com.zone")
+	if err := os.WriteFile(name, []byte(zone), 0600); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		args    []string
+		stdin   string
+		proxied string
+	}{
+		{[]string{"--file", name, "--proxied"}, "", "true"},
+		{[]string{"--file", "-"}, zone, "false"},
+	}
+	for _, test := range tests {
+		isolate(t)
+		s := newStandIn(t, func(w http.ResponseWriter, r *http.Request) {
+			writeResult(w, cloudflare.ImportResult{RecsAdded: 2, TotalRecordsParsed: 2}, nil)
+		})
+		if test.stdin != "" {
+			stdinFrom(t, test.stdin)
+		}
+
+		stdout, err := s.run(t, nil, append([]string{"import", "--zone-id", "zone"}, test.args...)...)
+		if err != nil {
+			t.Fatalf("%v: %s", test.args, err)
+		}
+		if !strings.Contains(stdout, `"recs_added":2`) {
+			t.Errorf("%v printed %q", test.args, stdout)
+		}
+		request := s.Requests()[0]
+		if request.Method != http.MethodPost || request.Path != "/client/v4/zones/zone/dns_records/import" {
+			t.Errorf("%v requested %s %s", test.args, request.Method, request.Path)
+		}
+
+		mediaType, params, err := mime.ParseMediaType(request.Header.Get("Content-Type"))
+		if err != nil || mediaType != "multipart/form-data" {
+			t.Fatalf("%v: content type %q, %v", test.args, request.Header.Get("Content-Type"), err)
+		}
+		form, err := multipart.NewReader(bytes.NewReader(request.Raw), params["boundary"]).ReadForm(1 << 20)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if proxied := form.Value["proxied"]; len(proxied) != 1 || proxied[0] != test.proxied {
+			t.Errorf("%v: proxied = %v, want %s", test.args, proxied, test.proxied)
+		}
+		files := form.File["file"]
+		if len(files) != 1 {
+			t.Fatalf("%v: %d file parts, want 1", test.args, len(files))
+		}
+		file, err := files[0].Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		raw, _ := io.ReadAll(file)
+		file.Close()
+		if string(raw) != zone {
+			t.Errorf("%v: file part = %q, want the zone file", test.args, raw)
+		}
+	}
+}
@@ -1,29 +1,134 @@
 package main
 
 import (
+	"cf-cli/cloudflare"
+	"context"
+	"fmt"
 	"github.com/urfave/cli/v2"
 	"os"
-)
-
-const (
-	BaseAPI = "https://api.cloudflare.com/client/v4"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
 func main() {
+	// The first SIGINT or SIGTERM cancels the running requests, a second one
+	// kills the process as usual.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
 	handler := &Handler{}
-	configuration, err := OpenSecurityConfiguration()
-	handler.Ready = err == nil && configuration != nil
-	handler.Configuration = configuration
+	if err := NewApp(handler).RunContext(ctx, os.Args); err != nil {
+		PrintError(err)
+		os.Exit(ExitCode(err))
+	}
+}
 
+func NewApp(handler *Handler) *cli.App {
+	// The version flag of urfave/cli claims -v, which belongs to --debug, so
+	// the app declares its own.
 	app := &cli.App{
 		Name:                 "cf-cli",
 		Version:              "0.0.1",
+		HideVersion:          true,
 		Usage:                "Cloudflare DNS Records for a Zone shell",
 		UsageText:            `cf-cli COMMAND [OPTIONS]`,
 		Suggest:              true,
 		EnableBashCompletion: true,
 		CommandNotFound: func(context *cli.Context, s string) {
 			FailPrintf("command '%s' not found", s)
+			os.Exit(ExitUsage)
+		},
+		ExitErrHandler: func(context *cli.Context, err error) {},
+		OnUsageError:   onUsageError,
+		Description: `Credentials are taken from, in order of precedence:
+  1. --api-token, or --x-auth-email together with --x-auth-key
+  2. CLOUDFLARE_API_TOKEN, or CLOUDFLARE_EMAIL together with CLOUDFLARE_API_KEY
+  3. the profile saved by 'cf-cli setup'
+The first source that has credentials is used as a whole, so an email and key
+given by flags win over a token in the environment. Within a source an API
+token wins over an email and key.
+
+The config file is --config, CF_CLI_CONFIG or $XDG_CONFIG_HOME/cf-cli/config.json,
+falling back to ~/.cf_cli_config when only that file exists.`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "api-token",
+				Usage: "Cloudflare API token, overrides CLOUDFLARE_API_TOKEN and the profile",
+			},
+			&cli.StringFlag{
+				Name:  "x-auth-email",
+				Usage: "Cloudflare auth email, overrides CLOUDFLARE_EMAIL and the profile together with --x-auth-key",
+			},
+			&cli.StringFlag{
+				Name:  "x-auth-key",
+				Usage: "Cloudflare auth key, overrides CLOUDFLARE_API_KEY and the profile together with --x-auth-email",
+			},
+			&cli.StringFlag{
+				Name:    "profile",
+				EnvVars: []string{"CF_PROFILE"},
+				Usage:   "Name of the credential profile to use, defaults to the one selected by 'profile use'",
+			},
+			&cli.StringFlag{
+				Name:    "api-base",
+				EnvVars: []string{"CF_API_BASE"},
+				Usage:   fmt.Sprintf("Base URL of the Cloudflare API, Eg. %s", cloudflare.DefaultBaseURL),
+			},
+			&cli.IntFlag{
+				Name:    "retries",
+				EnvVars: []string{"CF_RETRIES"},
+				Value:   cloudflare.DefaultRetryPolicy.MaxRetries,
+				Usage:   "How many times requests answered with 429 or a 5xx status are retried, waiting longer each time or as told by Retry-After up to 30s, a longer Retry-After fails at once. Only GET, PUT and DELETE are retried unless --retry-all-methods is given",
+			},
+			&cli.BoolFlag{
+				Name:  "retry-all-methods",
+				Usage: "Also retry POST and PATCH requests, which may then be applied twice",
+			},
+			&cli.Float64Flag{
+				Name:    "rate-limit",
+				EnvVars: []string{"CF_RATE_LIMIT"},
+				Value:   cloudflare.DefaultRateLimit,
+				Usage:   "Requests per second sent at most, slowing down further when Cloudflare reports the budget is used up. 0 means no limit",
+			},
+			&cli.DurationFlag{
+				Name:    "timeout",
+				EnvVars: []string{"CF_TIMEOUT"},
+				Value:   30 * time.Second,
+				Usage:   "Time limit of each request, including reading the response. 0 means no limit",
+			},
+			&cli.BoolFlag{
+				Name:    "debug",
+				Aliases: []string{"v", "verbose"},
+				Usage:   "Trace requests, responses, their timing and retries on stderr, with credentials redacted",
+			},
+			&cli.BoolFlag{
+				Name:  "dump-curl",
+				Usage: "Print each request as an equivalent curl command on stderr, reading the credentials from the environment",
+			},
+			&cli.StringFlag{
+				Name:    "config",
+				EnvVars: []string{"CF_CLI_CONFIG"},
+				Usage:   "Path of the config file, defaults to $XDG_CONFIG_HOME/cf-cli/config.json",
+			},
+			&cli.BoolFlag{
+				Name:  "version",
+				Usage: "print the version",
+			},
+		},
+		Before: handler.Prepare,
+		Action: func(c *cli.Context) error {
+			switch {
+			case c.Bool("version"):
+				cli.ShowVersion(c)
+				return nil
+			case c.Args().Present():
+				return cli.ShowCommandHelp(c, c.Args().First())
+			default:
+				return cli.ShowAppHelp(c)
+			}
 		},
 		Commands: []*cli.Command{
 			// setup
@@ -44,19 +149,103 @@ func main() {
 						Name:  "api-token",
 						Usage: "Cloudflare API token",
 					},
+					&cli.StringFlag{
+						Name:  "api-base",
+						Usage: "Base URL of the Cloudflare API, only needed when it is not the default one",
+					},
+					&cli.StringFlag{
+						Name:  "profile",
+						Usage: "Name of the profile to save the configuration to, defaults to the current profile",
+					},
+					&cli.StringFlag{
+						Name:  "zone",
+						Usage: "Name or identifier of the zone commands of the profile use when neither --zone-id nor --zone is given",
+					},
+					&cli.StringFlag{
+						Name:  "secret-backend",
+						Value: SecretBackendConfig,
+						Usage: "Where to keep the auth key and API token. Allowed values: config, keyring (OS secret store), file (encrypted with a passphrase, read from CF_CLI_PASSPHRASE when set)",
+					},
+					&cli.BoolFlag{
+						Name:  "skip-verify",
+						Usage: "Save the credentials without checking them against the API, Eg. when offline",
+					},
 				},
 				Action: handler.Setup,
 			},
 
+			// profile
+			{
+				Name:  "profile",
+				Usage: "Manage named credential profiles.",
+				Subcommands: []*cli.Command{
+					{
+						Name:   "list",
+						Usage:  "List the saved profiles.",
+						Flags:  OutputFlags,
+						Action: handler.ListProfiles,
+					},
+					{
+						Name:      "use",
+						Usage:     "Select the profile used when --profile is not given.",
+						ArgsUsage: "NAME",
+						Action:    handler.UseProfile,
+					},
+					{
+						Name:      "remove",
+						Aliases:   []string{"rm"},
+						Usage:     "Remove a saved profile.",
+						ArgsUsage: "NAME",
+						Action:    handler.RemoveProfile,
+					},
+				},
+			},
+
+			// config
+			{
+				Name:  "config",
+				Usage: "Manage the config file and the defaults of flags. Settings: " + settingKeys(),
+				Subcommands: []*cli.Command{
+					{
+						Name:      "get",
+						Usage:     "Print a setting.",
+						ArgsUsage: "KEY",
+						Flags:     SettingFlags,
+						Action:    handler.GetSetting,
+					},
+					{
+						Name:      "set",
+						Usage:     "Store a setting, used when its flag is not given.",
+						ArgsUsage: "KEY VALUE",
+						Flags:     SettingFlags,
+						Action:    handler.SetSetting,
+					},
+					{
+						Name:      "unset",
+						Usage:     "Remove a setting.",
+						ArgsUsage: "KEY",
+						Flags:     SettingFlags,
+						Action:    handler.UnsetSetting,
+					},
+					{
+						Name:   "path",
+						Usage:  "Print the path of the config file.",
+						Action: handler.ConfigPath,
+					},
+					{
+						Name:   "show",
+						Usage:  "Print the config file with secrets redacted.",
+						Flags:  OutputFlags,
+						Action: handler.ShowConfig,
+					},
+				},
+			},
+
 			// list
 			{
 				Name:  "list",
 				Usage: "List, search, sort, and filter a zones' DNS records.",
-				Flags: []cli.Flag{
-					&cli.StringFlag{
-						Name:  "zone-id",
-						Usage: "Identifier, <= 32 characters, Eg. 023e105f4ecef8ad9ca31a8372d0c353",
-					},
+				Flags: JoinFlags(ZoneFlags, []cli.Flag{
 					&cli.StringFlag{
 						Name:  "comment-exact",
 						Usage: "Exact value of the DNS record comment. Comment filters are case-insensitive. Eg. Hello, world",
@@ -114,6 +303,15 @@ func main() {
 						Value: 100,
 						Usage: "Number of DNS records per page. Eg. 5",
 					},
+					&cli.BoolFlag{
+						Name:  "all",
+						Usage: "Follow the pagination from --page to the last page and print the records as a single result, or page by page with --output ndjson.",
+					},
+					&cli.IntFlag{
+						Name:  "max-pages",
+						Value: 100,
+						Usage: "With --all, fail instead of fetching more than this many pages. 0 means no limit.",
+					},
 					&cli.BoolFlag{
 						Name:  "proxied",
 						Usage: "Whether the record is receiving the performance and security benefits of Cloudflare.",
@@ -155,326 +353,157 @@ func main() {
 						Name:  "type",
 						Usage: "Record type. Allowed values: A, AAAA, CAA, CERT, CNAME, DNSKEY, DS, HTTPS, LOC, MX, NAPTR, NS, PTR, SMIMEA, SRV, SSHFP, SVCB, TLSA, TXT, URI",
 					},
-				},
+				}, OutputFlags),
 				Action: handler.ListDNSRecords,
 			},
 
 			// create
 			{
-				Name:  "create",
-				Usage: "Create a new DNS record for a zone.",
-				Subcommands: []*cli.Command{
-					{
-						Name:    "A",
-						Aliases: []string{"a"},
-						Flags: []cli.Flag{
-							&cli.StringFlag{
-								Name:  "zone-id",
-								Usage: "Identifier, <= 32 characters, Eg. 023e105f4ecef8ad9ca31a8372d0c353",
-							},
-							&cli.StringFlag{
-								Name:  "content",
-								Usage: "A valid IPv4 address.",
-							},
-							&cli.StringFlag{
-								Name:  "name",
-								Usage: "DNS record name (or @ for the zone apex) in Punycode.",
-							},
-							&cli.BoolFlag{
-								Name:  "proxied",
-								Usage: "Whether the record is receiving the performance and security benefits of Cloudflare.",
-							},
-							&cli.StringFlag{
-								Name:  "comment",
-								Usage: "Comments or notes about the DNS record. This field has no effect on DNS responses.",
-							},
-							&cli.StringSliceFlag{
-								Name:  "tags",
-								Usage: "Custom tags for the DNS record. This field has no effect on DNS responses.",
-							},
-							&cli.Uint64Flag{
-								Name:  "ttl",
-								Usage: "Time To Live (TTL) of the DNS record in seconds. Setting to 1 means 'automatic'. Value must be between 60 and 86400, with the minimum reduced to 30 for Enterprise zones.",
-							},
-						},
-						Action: handler.CreateDNSRecordA,
-					},
-					{
-						Name:    "AAAA",
-						Aliases: []string{"aaaa"},
-						Flags: []cli.Flag{
-							&cli.StringFlag{
-								Name:  "zone-id",
-								Usage: "Identifier, <= 32 characters, Eg. 023e105f4ecef8ad9ca31a8372d0c353",
-							},
-							&cli.StringFlag{
-								Name:  "content",
-								Usage: "A valid IPv4 address.",
-							},
-							&cli.StringFlag{
-								Name:  "name",
-								Usage: "DNS record name (or @ for the zone apex) in Punycode.",
-							},
-							&cli.BoolFlag{
-								Name:  "proxied",
-								Usage: "Whether the record is receiving the performance and security benefits of Cloudflare.",
-							},
-							&cli.StringFlag{
-								Name:  "comment",
-								Usage: "Comments or notes about the DNS record. This field has no effect on DNS responses.",
-							},
-							&cli.StringSliceFlag{
-								Name:  "tags",
-								Usage: "Custom tags for the DNS record. This field has no effect on DNS responses.",
-							},
-							&cli.Uint64Flag{
-								Name:  "ttl",
-								Usage: "Time To Live (TTL) of the DNS record in seconds. Setting to 1 means 'automatic'. Value must be between 60 and 86400, with the minimum reduced to 30 for Enterprise zones.",
-							},
-						},
-						Action: handler.CreateDNSRecordAAAA,
-					},
-				},
+				Name:        "create",
+				Usage:       "Create a new DNS record for a zone.",
+				Subcommands: RecordCommands(handler.CreateDNSRecord),
 			},
 
 			// export
 			{
-				Name:  "export",
-				Usage: "You can export your BIND config through this endpoint.",
-				Flags: []cli.Flag{
-					&cli.StringFlag{
-						Name:  "zone-id",
-						Usage: "Identifier, <= 32 characters, Eg. 023e105f4ecef8ad9ca31a8372d0c353",
-					},
-				},
+				Name:   "export",
+				Usage:  "You can export your BIND config through this endpoint.",
+				Flags:  ZoneFlags,
 				Action: handler.ExportDNSRecords,
 			},
 
-			// scan
+			// import
 			{
-				Name:  "scan",
-				Usage: "Scan for common DNS records on your domain and automatically add them to your zone. Useful if you haven't updated your nameservers yet.",
-				Flags: []cli.Flag{
+				Name:  "import",
+				Usage: "You can upload your BIND config through this endpoint.",
+				Flags: JoinFlags(ZoneFlags, []cli.Flag{
 					&cli.StringFlag{
-						Name:  "zone-id",
-						Usage: "Identifier, <= 32 characters, Eg. 023e105f4ecef8ad9ca31a8372d0c353",
+						Name:  "file",
+						Usage: "BIND config to import, - for stdin. Eg. zone.txt",
 					},
-				},
+					&cli.BoolFlag{
+						Name:  "proxied",
+						Usage: "Whether or not proxiable records should receive the performance and security benefits of Cloudflare.",
+					},
+				}, OutputFlags),
+				Action: handler.ImportDNSRecords,
+			},
+
+			// scan
+			{
+				Name:   "scan",
+				Usage:  "Scan for common DNS records on your domain and automatically add them to your zone. Useful if you haven't updated your nameservers yet.",
+				Flags:  JoinFlags(ZoneFlags, OutputFlags),
 				Action: handler.ScanDNSRecord,
 			},
 
 			// delete
 			{
-				Name: "delete",
-				Flags: []cli.Flag{
-					&cli.StringFlag{
-						Name:  "zone-id",
-						Usage: "Identifier, <= 32 characters, Eg. 023e105f4ecef8ad9ca31a8372d0c353",
-					},
-					&cli.StringFlag{
-						Name:  "record-id",
-						Usage: "Identifier, <= 32 characters, Eg. 023e105f4ecef8ad9ca31a8372d0c353",
-					},
-				},
+				Name:   "delete",
+				Flags:  JoinFlags(ZoneFlags, SelectFlags),
 				Action: handler.DeleteDNSRecord,
 			},
 			{
-				Name: "details",
-				Flags: []cli.Flag{
-					&cli.StringFlag{
-						Name:  "zone-id",
-						Usage: "Identifier, <= 32 characters, Eg. 023e105f4ecef8ad9ca31a8372d0c353",
-					},
-					&cli.StringFlag{
-						Name:  "record-id",
-						Usage: "Identifier, <= 32 characters, Eg. 023e105f4ecef8ad9ca31a8372d0c353",
-					},
-				},
+				Name:   "details",
+				Flags:  JoinFlags(ZoneFlags, SelectFlags, OutputFlags),
 				Action: handler.DNSRecordDetails,
 			},
 
-			// update
+			// zones
 			{
-				Name: "update",
-				Usage: `Update an existing DNS record. Notes:
-A/AAAA records cannot exist on the same name as CNAME records.
-NS records cannot exist on the same name as any other record type.
-Domain names are always represented in Punycode, even if Unicode characters were used when creating the record.`,
+				Name:  "zones",
+				Usage: "Zones the credentials have access to.",
 				Subcommands: []*cli.Command{
 					{
-						Name:    "A",
-						Aliases: []string{"a"},
-						Flags: []cli.Flag{
-							&cli.StringFlag{
-								Name:  "zone-id",
-								Usage: "Identifier, <= 32 characters, Eg. 023e105f4ecef8ad9ca31a8372d0c353",
-							},
-							&cli.StringFlag{
-								Name:  "record-id",
-								Usage: "Identifier, <= 32 characters, Eg. 023e105f4ecef8ad9ca31a8372d0c353",
-							},
-							&cli.StringFlag{
-								Name:  "content",
-								Usage: "A valid IPv4 address.",
-							},
+						Name:  "list",
+						Usage: "List, search, and filter zones.",
+						Flags: JoinFlags([]cli.Flag{
 							&cli.StringFlag{
 								Name:  "name",
-								Usage: "DNS record name (or @ for the zone apex) in Punycode.",
-							},
-							&cli.BoolFlag{
-								Name:  "proxied",
-								Usage: "Whether the record is receiving the performance and security benefits of Cloudflare.",
+								Usage: "Domain name of the zone. Eg. example.com",
 							},
 							&cli.StringFlag{
-								Name:  "comment",
-								Usage: "Comments or notes about the DNS record. This field has no effect on DNS responses.",
-							},
-							&cli.StringSliceFlag{
-								Name:  "tags",
-								Usage: "Custom tags for the DNS record. This field has no effect on DNS responses.",
-							},
-							&cli.Uint64Flag{
-								Name:  "ttl",
-								Usage: "Time To Live (TTL) of the DNS record in seconds. Setting to 1 means 'automatic'. Value must be between 60 and 86400, with the minimum reduced to 30 for Enterprise zones.",
-							},
-						},
-						Action: handler.UpdateDNSRecordA,
-					},
-					{
-						Name:    "AAAA",
-						Aliases: []string{"aaaa"},
-						Flags: []cli.Flag{
-							&cli.StringFlag{
-								Name:  "zone-id",
-								Usage: "Identifier, <= 32 characters, Eg. 023e105f4ecef8ad9ca31a8372d0c353",
-							},
-							&cli.StringFlag{
-								Name:  "record-id",
-								Usage: "Identifier, <= 32 characters, Eg. 023e105f4ecef8ad9ca31a8372d0c353",
-							},
-							&cli.StringFlag{
-								Name:  "content",
-								Usage: "A valid IPv4 address.",
-							},
-							&cli.StringFlag{
-								Name:  "name",
-								Usage: "DNS record name (or @ for the zone apex) in Punycode.",
-							},
-							&cli.BoolFlag{
-								Name:  "proxied",
-								Usage: "Whether the record is receiving the performance and security benefits of Cloudflare.",
+								Name:  "status",
+								Usage: "Status of the zone. Allowed values: initializing, pending, active, moved",
 							},
 							&cli.StringFlag{
-								Name:  "comment",
-								Usage: "Comments or notes about the DNS record. This field has no effect on DNS responses.",
+								Name:  "account-id",
+								Usage: "Identifier of the account the zones belong to.",
 							},
-							&cli.StringSliceFlag{
-								Name:  "tags",
-								Usage: "Custom tags for the DNS record. This field has no effect on DNS responses.",
+							&cli.UintFlag{
+								Name:  "page",
+								Value: 1,
+								Usage: "Page number of paginated results.",
 							},
-							&cli.Uint64Flag{
-								Name:  "ttl",
-								Usage: "Time To Live (TTL) of the DNS record in seconds. Setting to 1 means 'automatic'. Value must be between 60 and 86400, with the minimum reduced to 30 for Enterprise zones.",
+							&cli.UintFlag{
+								Name:  "per-page",
+								Value: 50,
+								Usage: "Number of zones per page. Eg. 5",
 							},
-						},
-						Action: handler.UpdateDNSRecordAAAA,
+						}, OutputFlags),
+						Action: handler.ListZones,
 					},
 				},
 			},
 
-			// overwrite
+			// api
 			{
-				Name: "overwrite",
-				Usage: `Overwrite an existing DNS record. Notes:
+				Name:      "api",
+				Usage:     "Send any request to the Cloudflare API and print its result.",
+				ArgsUsage: "METHOD PATH",
+				Description: `PATH is relative to the API base, {zone_id} is replaced by the zone given by
+--zone-id or --zone, or the default zone. Eg.
+  cf-cli api GET /zones/{zone_id}/dns_settings
+  cf-cli api PATCH /zones/{zone_id}/dnssec --field status=active
+  cf-cli api POST /zones/{zone_id}/dns_records --input record.json`,
+				Flags:  JoinFlags(ZoneFlags, APIFlags, OutputFlags),
+				Action: handler.API,
+			},
+
+			// update
+			{
+				Name: "update",
+				Usage: `Update an existing DNS record. Only the fields that are given are changed. Notes:
 A/AAAA records cannot exist on the same name as CNAME records.
 NS records cannot exist on the same name as any other record type.
 Domain names are always represented in Punycode, even if Unicode characters were used when creating the record.`,
-				Subcommands: []*cli.Command{
-					{
-						Name:    "A",
-						Aliases: []string{"a"},
-						Flags: []cli.Flag{
-							&cli.StringFlag{
-								Name:  "zone-id",
-								Usage: "Identifier, <= 32 characters, Eg. 023e105f4ecef8ad9ca31a8372d0c353",
-							},
-							&cli.StringFlag{
-								Name:  "record-id",
-								Usage: "Identifier, <= 32 characters, Eg. 023e105f4ecef8ad9ca31a8372d0c353",
-							},
-							&cli.StringFlag{
-								Name:  "content",
-								Usage: "A valid IPv4 address.",
-							},
-							&cli.StringFlag{
-								Name:  "name",
-								Usage: "DNS record name (or @ for the zone apex) in Punycode.",
-							},
-							&cli.BoolFlag{
-								Name:  "proxied",
-								Usage: "Whether the record is receiving the performance and security benefits of Cloudflare.",
-							},
-							&cli.StringFlag{
-								Name:  "comment",
-								Usage: "Comments or notes about the DNS record. This field has no effect on DNS responses.",
-							},
-							&cli.StringSliceFlag{
-								Name:  "tags",
-								Usage: "Custom tags for the DNS record. This field has no effect on DNS responses.",
-							},
-							&cli.Uint64Flag{
-								Name:  "ttl",
-								Usage: "Time To Live (TTL) of the DNS record in seconds. Setting to 1 means 'automatic'. Value must be between 60 and 86400, with the minimum reduced to 30 for Enterprise zones.",
-							},
+				Subcommands: RecordCommands(
+					handler.UpdateDNSRecord,
+					JoinFlags(WriteSelectFlags, []cli.Flag{
+						&cli.BoolFlag{
+							Name:  "clear-comment",
+							Usage: "Remove the comment from the DNS record.",
 						},
-						Action: handler.OverwriteDNSRecordA,
-					},
-					{
-						Name:    "AAAA",
-						Aliases: []string{"aaaa"},
-						Flags: []cli.Flag{
-							&cli.StringFlag{
-								Name:  "zone-id",
-								Usage: "Identifier, <= 32 characters, Eg. 023e105f4ecef8ad9ca31a8372d0c353",
-							},
-							&cli.StringFlag{
-								Name:  "record-id",
-								Usage: "Identifier, <= 32 characters, Eg. 023e105f4ecef8ad9ca31a8372d0c353",
-							},
-							&cli.StringFlag{
-								Name:  "content",
-								Usage: "A valid IPv4 address.",
-							},
-							&cli.StringFlag{
-								Name:  "name",
-								Usage: "DNS record name (or @ for the zone apex) in Punycode.",
-							},
-							&cli.BoolFlag{
-								Name:  "proxied",
-								Usage: "Whether the record is receiving the performance and security benefits of Cloudflare.",
-							},
-							&cli.StringFlag{
-								Name:  "comment",
-								Usage: "Comments or notes about the DNS record. This field has no effect on DNS responses.",
-							},
-							&cli.StringSliceFlag{
-								Name:  "tags",
-								Usage: "Custom tags for the DNS record. This field has no effect on DNS responses.",
-							},
-							&cli.Uint64Flag{
-								Name:  "ttl",
-								Usage: "Time To Live (TTL) of the DNS record in seconds. Setting to 1 means 'automatic'. Value must be between 60 and 86400, with the minimum reduced to 30 for Enterprise zones.",
-							},
+						&cli.BoolFlag{
+							Name:  "clear-tags",
+							Usage: "Remove all tags from the DNS record.",
 						},
-						Action: handler.OverwriteDNSRecordAAAA,
-					},
-				},
+					})...,
+				),
+			},
+
+			// overwrite
+			{
+				Name: "overwrite",
+				Usage: `Overwrite an existing DNS record. Unlike update, fields that are not given are reset. Notes:
+A/AAAA records cannot exist on the same name as CNAME records.
+NS records cannot exist on the same name as any other record type.
+Domain names are always represented in Punycode, even if Unicode characters were used when creating the record.`,
+				Subcommands: RecordCommands(
+					handler.OverwriteDNSRecord,
+					WriteSelectFlags...,
+				),
 			},
 		},
 	}
+	prepareCommands(app.Commands, handler)
+	return app
+}
 
-	if err = app.Run(os.Args); err != nil {
-		FailPrintf(err.Error())
-		return
+func prepareCommands(commands []*cli.Command, handler *Handler) {
+	for _, command := range commands {
+		command.Before = handler.ApplyDefaults
+		command.OnUsageError = onUsageError
+		prepareCommands(command.Subcommands, handler)
 	}
 }
@@ -0,0 +1,487 @@
+package main
+
+import (
+	"cf-cli/cloudflare"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/term"
+	"gopkg.in/yaml.v3"
+	"io"
+	"os"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+)
+
+var OutputFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:    "output",
+		Aliases: []string{"o"},
+		Value:   "json",
+		Usage:   "Output format. Allowed values: json, table, csv, yaml, ndjson",
+	},
+	&cli.StringSliceFlag{
+		Name:  "columns",
+		Usage: "Columns of the table and csv output. Allowed values for records: type, name, content, ttl, proxied, comment, tags, id",
+	},
+	&cli.StringFlag{
+		Name:  "format",
+		Usage: "Go template printed once per record instead of --output. Eg. '{{.ID}} {{.Name}}={{.Content}}'",
+	},
+	&cli.StringSliceFlag{
+		Name:  "fields",
+		Usage: "Only print these JSON fields, nested fields are separated by dots. Eg. id,name,data.priority",
+	},
+	&cli.StringFlag{
+		Name:  "sort",
+		Usage: "Column to sort the records by, prefix with '-' for descending order. Eg. -ttl",
+	},
+}
+
+var outputFormats = []string{"json", "table", "csv", "yaml", "ndjson"}
+
+var defaultColumns = []string{"type", "name", "content", "ttl", "proxied", "comment", "tags", "id"}
+
+var recordColumns = map[string]func(r cloudflare.DNSRecord) string{
+	"type":    func(r cloudflare.DNSRecord) string { return r.Type },
+	"name":    func(r cloudflare.DNSRecord) string { return r.Name },
+	"content": func(r cloudflare.DNSRecord) string { return r.Content },
+	"ttl":     func(r cloudflare.DNSRecord) string { return strconv.FormatUint(r.TTL, 10) },
+	"proxied": func(r cloudflare.DNSRecord) string { return strconv.FormatBool(r.Proxied) },
+	"comment": func(r cloudflare.DNSRecord) string { return r.Comment },
+	"tags":    func(r cloudflare.DNSRecord) string { return strings.Join(r.Tags, ",") },
+	"id":      func(r cloudflare.DNSRecord) string { return r.ID },
+}
+
+type Output struct {
+	Format   string
+	Columns  []string
+	Fields   []string
+	Template *template.Template
+	Sort     string
+	Width    int
+	Writer   io.Writer
+}
+
+var templateFuncs = template.FuncMap{
+	"join": strings.Join,
+	"json": func(v any) (string, error) {
+		raw, err := json.Marshal(v)
+		return string(raw), err
+	},
+}
+
+// NewOutput checks the output flags of commands that do not print records,
+// before any request is sent.
+func NewOutput(c *cli.Context) (*Output, error) {
+	return newOutput(c, false)
+}
+
+// NewRecordOutput also accepts record columns in --columns and --sort.
+func NewRecordOutput(c *cli.Context) (*Output, error) {
+	return newOutput(c, true)
+}
+
+func newOutput(c *cli.Context, records bool) (*Output, error) {
+	o := &Output{
+		Format: c.String("output"),
+		Sort:   c.String("sort"),
+		Writer: os.Stdout,
+	}
+	if o.Format == "" {
+		o.Format = "json"
+	}
+	if err := validateOutputFormat(o.Format); err != nil {
+		return nil, &UsageError{Err: err}
+	}
+	for _, column := range c.StringSlice("columns") {
+		for _, name := range strings.Split(column, ",") {
+			if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+				o.Columns = append(o.Columns, name)
+			}
+		}
+	}
+	for _, field := range c.StringSlice("fields") {
+		for _, name := range strings.Split(field, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				o.Fields = append(o.Fields, name)
+			}
+		}
+	}
+	if format := c.String("format"); format != "" {
+		tmpl, err := template.New("format").Funcs(templateFuncs).Parse(format)
+		if err != nil {
+			return nil, &UsageError{Err: errors.New("invalid --format template, cause: " + err.Error())}
+		}
+		o.Template = tmpl
+	}
+	if records {
+		if err := o.checkColumns(); err != nil {
+			return nil, &UsageError{Err: err}
+		}
+	} else if o.Sort != "" {
+		return nil, &UsageError{Err: errors.New("--sort only applies to DNS records")}
+	}
+	if width, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && width > 0 {
+		o.Width = width
+	} else if width, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil {
+		o.Width = width
+	}
+	return o, nil
+}
+
+// Streams reports whether records can be printed page by page as they arrive.
+func (o *Output) Streams() bool {
+	return (o.Format == "ndjson" || o.Template != nil) && o.Sort == ""
+}
+
+func (o *Output) Records(records []cloudflare.DNSRecord, info *cloudflare.ResultInfo) error {
+	columns, rows, err := o.recordRows(records)
+	if err != nil {
+		return err
+	}
+	items := make([]any, len(records))
+	for i, record := range records {
+		items[i] = record
+	}
+	return o.print(records, info, items, columns, rows)
+}
+
+func (o *Output) Record(record *cloudflare.DNSRecord) error {
+	columns, rows, err := o.recordRows([]cloudflare.DNSRecord{*record})
+	if err != nil {
+		return err
+	}
+	return o.print(record, nil, []any{*record}, columns, rows)
+}
+
+func validateOutputFormat(format string) error {
+	if !slices.Contains(outputFormats, format) {
+		return fmt.Errorf("unknown output format '%s', allowed values: %s", format, strings.Join(outputFormats, ", "))
+	}
+	return nil
+}
+
+// checkColumns rejects --columns and --sort that are not record columns.
+func (o *Output) checkColumns() error {
+	for _, column := range append([]string{strings.TrimPrefix(o.Sort, "-")}, o.Columns...) {
+		if _, ok := recordColumns[column]; column != "" && !ok {
+			return fmt.Errorf("unknown column '%s'", column)
+		}
+	}
+	return nil
+}
+
+func (o *Output) recordRows(records []cloudflare.DNSRecord) ([]string, [][]string, error) {
+	if err := o.checkColumns(); err != nil {
+		return nil, nil, err
+	}
+	columns := o.Columns
+	if len(columns) == 0 {
+		columns = defaultColumns
+	}
+	o.sort(records)
+
+	rows := make([][]string, len(records))
+	for i, record := range records {
+		rows[i] = make([]string, len(columns))
+		for j, column := range columns {
+			rows[i][j] = recordColumns[column](record)
+			// Tables are read by people, csv keeps the number for scripts.
+			if column == "ttl" && record.TTL == 1 && o.Format == "table" {
+				rows[i][j] = "auto"
+			}
+		}
+	}
+	return columns, rows, nil
+}
+
+// List prints a list of any other objects, using JSON field paths as columns.
+func (o *Output) List(list any, info *cloudflare.ResultInfo, columns ...string) error {
+	if len(o.Columns) > 0 {
+		columns = o.Columns
+	}
+	object, err := toJSONValue(list)
+	if err != nil {
+		return err
+	}
+	elements, _ := object.([]any)
+	value := reflect.ValueOf(list)
+	items := make([]any, len(elements))
+	rows := make([][]string, len(elements))
+	for i, element := range elements {
+		items[i] = value.Index(i).Interface()
+		rows[i] = make([]string, len(columns))
+		for j, column := range columns {
+			value, _ := lookupField(element, column)
+			rows[i][j] = cellValue(value)
+		}
+	}
+	return o.print(list, info, items, columns, rows)
+}
+
+// Value prints a result that is not a list of records, using its JSON field
+// names as columns.
+func (o *Output) Value(v any) error {
+	object, err := toJSONValue(v)
+	if err != nil {
+		return err
+	}
+	fields, ok := object.(map[string]any)
+	if !ok {
+		return o.print(v, nil, []any{v}, []string{"result"}, [][]string{{fmt.Sprint(object)}})
+	}
+	columns := make([]string, 0, len(fields))
+	for key := range fields {
+		columns = append(columns, key)
+	}
+	sort.Strings(columns)
+	row := make([]string, len(columns))
+	for i, column := range columns {
+		row[i] = cellValue(fields[column])
+	}
+	return o.print(v, nil, []any{v}, columns, [][]string{row})
+}
+
+func (o *Output) print(result any, info *cloudflare.ResultInfo, items []any, columns []string, rows [][]string) error {
+	if o.Template != nil {
+		for _, item := range items {
+			if err := o.Template.Execute(o.Writer, item); err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintln(o.Writer); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if len(o.Fields) > 0 {
+		var err error
+		if result, err = o.project(result); err != nil {
+			return err
+		}
+		columns, rows = o.Fields, make([][]string, len(items))
+		for i, item := range items {
+			object, err := toJSONValue(item)
+			if err != nil {
+				return err
+			}
+			rows[i] = make([]string, len(o.Fields))
+			for j, field := range o.Fields {
+				value, _ := lookupField(object, field)
+				rows[i][j] = cellValue(value)
+			}
+			if items[i], err = o.project(item); err != nil {
+				return err
+			}
+		}
+	}
+
+	switch o.Format {
+	case "json":
+		return WriteResult(o.Writer, result, info)
+	case "yaml":
+		object, err := toJSONValue(result)
+		if err != nil {
+			return err
+		}
+		encoder := yaml.NewEncoder(o.Writer)
+		encoder.SetIndent(2)
+		if err = encoder.Encode(object); err != nil {
+			return err
+		}
+		return encoder.Close()
+	case "ndjson":
+		encoder := json.NewEncoder(o.Writer)
+		for _, item := range items {
+			if err := encoder.Encode(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "csv":
+		writer := csv.NewWriter(o.Writer)
+		writer.Write(columns)
+		writer.WriteAll(rows)
+		return writer.Error()
+	case "table":
+		return o.table(columns, rows)
+	default:
+		return errors.New("unknown output format '" + o.Format + "'")
+	}
+}
+
+func (o *Output) sort(records []cloudflare.DNSRecord) {
+	key := strings.TrimPrefix(o.Sort, "-")
+	if key == "" {
+		return
+	}
+	less := func(a, b cloudflare.DNSRecord) bool {
+		if key == "ttl" {
+			return a.TTL < b.TTL
+		}
+		return recordColumns[key](a) < recordColumns[key](b)
+	}
+	descending := strings.HasPrefix(o.Sort, "-")
+	sort.SliceStable(records, func(i, j int) bool {
+		if descending {
+			return less(records[j], records[i])
+		}
+		return less(records[i], records[j])
+	})
+}
+
+func (o *Output) table(columns []string, rows [][]string) error {
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = strings.ToUpper(column)
+	}
+	rows = append([][]string{header}, rows...)
+
+	widths := make([]int, len(columns))
+	for _, row := range rows {
+		for i, cell := range row {
+			row[i] = strings.ReplaceAll(cell, "\n", " ")
+			widths[i] = max(widths[i], utf8.RuneCountInString(row[i]))
+		}
+	}
+	o.fit(widths)
+
+	for _, row := range rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cell = truncate(cell, widths[i])
+			if i < len(row)-1 {
+				cell += strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell))
+			}
+			cells[i] = cell
+		}
+		if _, err := fmt.Fprintln(o.Writer, strings.Join(cells, "  ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fit narrows the widest columns until the table fits into o.Width.
+func (o *Output) fit(widths []int) {
+	if o.Width <= 0 {
+		return
+	}
+	const minWidth = 8
+	total := 2 * (len(widths) - 1)
+	for _, width := range widths {
+		total += width
+	}
+	for total > o.Width {
+		widest := -1
+		for i, width := range widths {
+			if width > minWidth && (widest < 0 || width > widths[widest]) {
+				widest = i
+			}
+		}
+		if widest < 0 {
+			return
+		}
+		widths[widest]--
+		total--
+	}
+}
+
+func truncate(s string, width int) string {
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	return string([]rune(s)[:width-1]) + "…"
+}
+
+// project keeps only o.Fields of v, or of every element when v is a list.
+func (o *Output) project(v any) (any, error) {
+	object, err := toJSONValue(v)
+	if err != nil {
+		return nil, err
+	}
+	if list, ok := object.([]any); ok {
+		for i, element := range list {
+			list[i] = projectFields(element, o.Fields)
+		}
+		return list, nil
+	}
+	return projectFields(object, o.Fields), nil
+}
+
+func projectFields(object any, fields []string) map[string]any {
+	projected := map[string]any{}
+	for _, field := range fields {
+		value, ok := lookupField(object, field)
+		if !ok {
+			continue
+		}
+		parts := strings.Split(field, ".")
+		parent := projected
+		for _, part := range parts[:len(parts)-1] {
+			child, ok := parent[part].(map[string]any)
+			if !ok {
+				child = map[string]any{}
+				parent[part] = child
+			}
+			parent = child
+		}
+		parent[parts[len(parts)-1]] = value
+	}
+	return projected
+}
+
+func lookupField(object any, field string) (any, bool) {
+	for _, part := range strings.Split(field, ".") {
+		fields, ok := object.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if object, ok = fields[part]; !ok {
+			return nil, false
+		}
+	}
+	return object, true
+}
+
+func cellValue(v any) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case []any:
+		cells := make([]string, len(value))
+		for i, element := range value {
+			cells[i] = cellValue(element)
+		}
+		return strings.Join(cells, ",")
+	case map[string]any:
+		raw, _ := json.Marshal(value)
+		return string(raw)
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// toJSONValue turns v into maps and slices keyed by its JSON field names.
+func toJSONValue(v any) (any, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var object any
+	if err = json.Unmarshal(raw, &object); err != nil {
+		return nil, err
+	}
+	return object, nil
+}
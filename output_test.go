@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"cf-cli/cloudflare"
+	"os"
+	"path/filepath"
+	"testing"
+	"text/template"
+)
+
+func testRecords() []cloudflare.DNSRecord {
+	return []cloudflare.DNSRecord{
+		{ID: "id1", Name: "www.example.com", Type: "A", Content: "192.0.2.1", TTL: 1, Proxied: true, Comment: "front door of the example web site", Tags: []string{"web", "prod"}},
+		{ID: "id2", Name: "example.com", Type: "CAA", Content: `0 issue "ca.example.net"`, TTL: 3600, Data: map[string]any{"flags": 0, "tag": "issue", "value": "ca.example.net"}},
+	}
+}
+
+func TestOutputRecords(t *testing.T) {
+	tests := []struct {
+		name   string
+		output Output
+		want   string
+	}{
+		{
+			name:   "json",
+			output: Output{Format: "json", Fields: []string{"id", "ttl"}},
+			want: `{"errors":[],"messages":[],"result":[{"id":"id1","ttl":1},{"id":"id2","ttl":3600}],"result_info":null,"success":true}
+`,
+		},
+		{
+			name:   "table",
+			output: Output{Format: "table"},
+			want: `TYPE  NAME             CONTENT                   TTL   PROXIED  COMMENT                             TAGS      ID
+A     www.example.com  192.0.2.1                 auto  true     front door of the example web site  web,prod  id1
+CAA   example.com      0 issue "ca.example.net"  3600  false                                                  id2
+`,
+		},
+		{
+			name:   "table sorted and fitted",
+			output: Output{Format: "table", Columns: []string{"name", "content", "comment", "ttl"}, Sort: "-ttl", Width: 60},
+			want: `NAME             CONTENT            COMMENT             TTL
+example.com      0 issue "ca.exam…                      3600
+www.example.com  192.0.2.1          front door of the…  auto
+`,
+		},
+		{
+			name:   "table narrower than its columns",
+			output: Output{Format: "table", Columns: []string{"name", "id"}, Width: 10},
+			want: `NAME      ID
+www.exa…  id1
+example…  id2
+`,
+		},
+		{
+			name:   "csv",
+			output: Output{Format: "csv", Columns: []string{"type", "content", "ttl", "tags"}},
+			want: `type,content,ttl,tags
+A,192.0.2.1,1,"web,prod"
+CAA,"0 issue ""ca.example.net""",3600,
+`,
+		},
+		{
+			name:   "yaml",
+			output: Output{Format: "yaml", Fields: []string{"id", "ttl", "data.tag"}},
+			want: `- id: id1
+  ttl: 1
+- data:
+    tag: issue
+  id: id2
+  ttl: 3600
+`,
+		},
+		{
+			name:   "ndjson",
+			output: Output{Format: "ndjson", Fields: []string{"name", "data.tag", "data.missing"}},
+			want: `{"name":"www.example.com"}
+{"data":{"tag":"issue"},"name":"example.com"}
+`,
+		},
+		{
+			name:   "csv of fields",
+			output: Output{Format: "csv", Fields: []string{"name", "data.value"}},
+			want: `name,data.value
+www.example.com,
+example.com,ca.example.net
+`,
+		},
+		{
+			name:   "format",
+			output: Output{Format: "table", Template: template.Must(template.New("format").Funcs(templateFuncs).Parse(`{{.Name}} {{.TTL}} {{join .Tags "+"}}`))},
+			want: `www.example.com 1 web+prod
+example.com 3600 
+`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			test.output.Writer = out
+			if err := test.output.Records(testRecords(), nil); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != test.want {
+				t.Errorf("printed\n%s\nwant\n%s", out, test.want)
+			}
+		})
+	}
+}
+
+func TestOutputUnknownColumn(t *testing.T) {
+	for _, output := range []Output{{Format: "table", Columns: []string{"owner"}}, {Format: "table", Sort: "owner"}} {
+		output.Writer = &bytes.Buffer{}
+		if err := output.Records(testRecords(), nil); err == nil || err.Error() != "unknown column 'owner'" {
+			t.Errorf("%+v: err = %v", output, err)
+		}
+	}
+}
+
+func TestOutputFlagsCheckedBeforeRequests(t *testing.T) {
+	isolate(t)
+	name := filepath.Join(t.TempDir(), "zone.txt")
+	if err := os.WriteFile(name, []byte("www.example.com. 300 IN A 192.0.2.1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	s := newStandIn(t, nil)
+	for _, args := range [][]string{
+		{"scan", "--zone-id", "zone", "-o", "xml"},
+		{"import", "--zone-id", "zone", "--file", name, "-o", "xml"},
+		{"list", "--zone", "example.com", "--sort", "owner"},
+		{"details", "--zone", "example.com", "--name", "www.example.com", "--columns", "name,owner"},
+		{"list", "--zone-id", "zone", "--format", "{{.Name"},
+		{"zones", "list", "--sort", "name"},
+		{"api", "POST", "/zones/zone/dns_records/scan", "-o", "xml"},
+	} {
+		_, err := s.run(t, nil, args...)
+		if ExitCode(err) != ExitUsage {
+			t.Errorf("%v: exit code %d, want %d, err %v", args, ExitCode(err), ExitUsage, err)
+		}
+	}
+	if requests := s.Requests(); len(requests) != 0 {
+		t.Errorf("invalid output flags sent %d requests", len(requests))
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"github.com/urfave/cli/v2"
+)
+
+type ProfileSummary struct {
+	Name       string `json:"name"`
+	Current    bool   `json:"current"`
+	Auth       string `json:"auth"`
+	Secrets    string `json:"secrets"`
+	XAuthEmail string `json:"x_auth_email,omitempty"`
+	APIBase    string `json:"api_base,omitempty"`
+	Zone       string `json:"zone,omitempty"`
+}
+
+func (h *Handler) ListProfiles(c *cli.Context) error {
+	output, err := NewOutput(c)
+	if err != nil {
+		return err
+	}
+
+	profiles := make([]ProfileSummary, 0, len(h.File.Profiles))
+	for _, name := range h.File.ProfileNames() {
+		profile := h.File.Profiles[name]
+		summary := ProfileSummary{
+			Name:       name,
+			Current:    name == h.Profile,
+			Secrets:    SecretBackendConfig,
+			XAuthEmail: profile.XAuthEmail,
+			APIBase:    profile.APIBase,
+			Zone:       profile.Zone,
+		}
+		switch {
+		case profile.Locked():
+			summary.Secrets = profile.SecretBackend
+		case profile.APIToken != "":
+			summary.Auth = "api-token"
+		case profile.XAuthKey != "":
+			summary.Auth = "x-auth-key"
+		}
+		profiles = append(profiles, summary)
+	}
+
+	return output.List(profiles, nil, "name", "current", "auth", "secrets", "x_auth_email", "api_base", "zone")
+}
+
+func (h *Handler) UseProfile(c *cli.Context) error {
+	name := c.Args().First()
+	if _, err := h.File.Profile(name); err != nil {
+		return err
+	}
+	h.File.CurrentProfile = name
+	return h.File.Save()
+}
+
+func (h *Handler) RemoveProfile(c *cli.Context) error {
+	name := c.Args().First()
+	profile, err := h.File.Profile(name)
+	if err != nil {
+		return err
+	}
+	if err = h.File.Forget(name, profile); err != nil {
+		return err
+	}
+	delete(h.File.Profiles, name)
+	if h.File.CurrentProfile == name {
+		h.File.CurrentProfile = ""
+	}
+	return h.File.Save()
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestProfileSelection(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     string
+		args    []string
+		current string
+		want    string
+	}{
+		{name: "current profile", current: "prod", want: "prod-token"},
+		{name: "default profile", want: "default-token"},
+		{name: "env", env: "prod", want: "prod-token"},
+		{name: "flag over env", env: "prod", args: []string{"--profile", DefaultProfile}, want: "default-token"},
+		{name: "env over current profile", env: DefaultProfile, current: "prod", want: "default-token"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			isolate(t)
+			t.Setenv("CF_PROFILE", test.env)
+			name := saveConfig(t, nil, map[string]*SecurityConfiguration{
+				DefaultProfile: {APIToken: "default-token"},
+				"prod":         {APIToken: "prod-token"},
+			})
+			file, _ := OpenConfigFile(name)
+			file.CurrentProfile = test.current
+			if err := file.Save(); err != nil {
+				t.Fatal(err)
+			}
+			s := newStandIn(t, nil)
+
+			if _, err := s.run(t, &Handler{}, append(test.args, "details", "--zone-id", "zone", "--record-id", "record")...); err != nil {
+				t.Fatal(err)
+			}
+			if got := s.Requests()[0].Header.Get("Authorization"); got != "Bearer "+test.want {
+				t.Errorf("Authorization = %q, want the token of %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestProfileCommands(t *testing.T) {
+	isolate(t)
+	name := saveConfig(t, nil, map[string]*SecurityConfiguration{
+		DefaultProfile: {APIToken: "default-token"},
+		"prod":         {XAuthEmail: "someone@example.com", XAuthKey: "key", Zone: "prod.com"},
+	})
+	s := newStandIn(t, nil)
+	run := func(args ...string) (string, error) {
+		t.Helper()
+		return s.run(t, &Handler{}, args...)
+	}
+	list := func() []ProfileSummary {
+		t.Helper()
+		stdout, err := run("profile", "list", "--output", "json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var envelope struct {
+			Result []ProfileSummary `json:"result"`
+		}
+		if err = json.Unmarshal([]byte(stdout), &envelope); err != nil {
+			t.Fatalf("failed to parse %q: %s", stdout, err)
+		}
+		return envelope.Result
+	}
+
+	want := []ProfileSummary{
+		{Name: DefaultProfile, Current: true, Auth: "api-token", Secrets: SecretBackendConfig},
+		{Name: "prod", Auth: "x-auth-key", Secrets: SecretBackendConfig, XAuthEmail: "someone@example.com", Zone: "prod.com"},
+	}
+	if got := list(); len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("profiles = %+v, want %+v", got, want)
+	}
+
+	if _, err := run("profile", "use", "prod"); err != nil {
+		t.Fatal(err)
+	}
+	if file, _ := OpenConfigFile(name); file.CurrentProfile != "prod" {
+		t.Errorf("current profile = %q after use, want prod", file.CurrentProfile)
+	}
+	if got := list(); got[0].Current || !got[1].Current {
+		t.Errorf("profiles = %+v, want prod current", got)
+	}
+	if _, err := run("profile", "use", "missing"); err == nil || !strings.Contains(err.Error(), "profile 'missing' does not exist") {
+		t.Errorf("use of a missing profile: err = %v", err)
+	}
+
+	if _, err := run("profile", "remove", "prod"); err != nil {
+		t.Fatal(err)
+	}
+	file, _ := OpenConfigFile(name)
+	if _, ok := file.Profiles["prod"]; ok || file.CurrentProfile != "" {
+		t.Errorf("after removing the current profile: profiles %v, current %q", file.ProfileNames(), file.CurrentProfile)
+	}
+	if _, err := run("details", "--zone-id", "zone", "--record-id", "record"); err != nil {
+		t.Fatal(err)
+	}
+	requests := s.Requests()
+	if got := requests[len(requests)-1].Header.Get("Authorization"); got != "Bearer default-token" {
+		t.Errorf("Authorization = %q after removing the current profile, want the default token", got)
+	}
+	if _, err := run("profile", "rm", "prod"); err == nil {
+		t.Errorf("removing a removed profile succeeded")
+	}
+}
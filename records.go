@@ -0,0 +1,429 @@
+package main
+
+import (
+	"cf-cli/cloudflare"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+	"math"
+	"strings"
+)
+
+type DataKind int
+
+const (
+	DataString DataKind = iota
+	DataUint
+	DataFloat
+)
+
+type DataField struct {
+	Name  string
+	Kind  DataKind
+	Usage string
+}
+
+func (f DataField) FlagName() string {
+	return strings.ReplaceAll(f.Name, "_", "-")
+}
+
+func (f DataField) Flag() cli.Flag {
+	switch f.Kind {
+	case DataUint:
+		return &cli.Uint64Flag{Name: f.FlagName(), Usage: f.Usage}
+	case DataFloat:
+		return &cli.Float64Flag{Name: f.FlagName(), Usage: f.Usage}
+	default:
+		return &cli.StringFlag{Name: f.FlagName(), Usage: f.Usage}
+	}
+}
+
+func (f DataField) Value(c *cli.Context) any {
+	switch f.Kind {
+	case DataUint:
+		return c.Uint64(f.FlagName())
+	case DataFloat:
+		return c.Float64(f.FlagName())
+	default:
+		return c.String(f.FlagName())
+	}
+}
+
+var SelectFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:  "record-id",
+		Usage: "Identifier, <= 32 characters, Eg. 023e105f4ecef8ad9ca31a8372d0c353",
+	},
+	&cli.StringFlag{
+		Name:  "name",
+		Usage: "Select the record by its name when --record-id is not given. Eg. www.example.com",
+	},
+	&cli.StringFlag{
+		Name:  "type",
+		Usage: "Only select records of this type. Eg. A",
+	},
+	&cli.StringFlag{
+		Name:  "content",
+		Usage: "Only select records with this content. Eg. 127.0.0.1",
+	},
+	&cli.BoolFlag{
+		Name:  "all-matches",
+		Usage: "Act on every record matching the selection instead of failing when there is more than one.",
+	},
+}
+
+// WriteSelectFlags select the records of update and overwrite, whose --name
+// and --content flags are the new values instead.
+var WriteSelectFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:  "record-id",
+		Usage: "Identifier, <= 32 characters, Eg. 023e105f4ecef8ad9ca31a8372d0c353",
+	},
+	&cli.StringFlag{
+		Name:  "match-name",
+		Usage: "Select the record by its name when --record-id is not given, defaults to --name. Eg. www.example.com",
+	},
+	&cli.StringFlag{
+		Name:  "match-content",
+		Usage: "Only select records with this content. Eg. 127.0.0.1",
+	},
+	&cli.BoolFlag{
+		Name:  "all-matches",
+		Usage: "Act on every record matching the selection instead of failing when there is more than one.",
+	},
+}
+
+// RecordType describes how a DNS record type is expressed on the command line
+// and in the request body. Types without ContentUsage are built from Data.
+type RecordType struct {
+	Name         string
+	ContentUsage string
+	Proxiable    bool
+	Priority     bool
+	Data         []DataField
+}
+
+var (
+	svcbData = []DataField{
+		{Name: "priority", Kind: DataUint, Usage: "Priority."},
+		{Name: "target", Kind: DataString, Usage: "Target."},
+		{Name: "value", Kind: DataString, Usage: "Value. Eg. alpn=\"h3,h2\" ipv4hint=\"127.0.0.1\""},
+	}
+	tlsaData = []DataField{
+		{Name: "certificate", Kind: DataString, Usage: "Certificate."},
+		{Name: "matching_type", Kind: DataUint, Usage: "Matching Type."},
+		{Name: "selector", Kind: DataUint, Usage: "Selector."},
+		{Name: "usage", Kind: DataUint, Usage: "Usage."},
+	}
+)
+
+var RecordTypes = []RecordType{
+	{Name: "A", ContentUsage: "A valid IPv4 address.", Proxiable: true},
+	{Name: "AAAA", ContentUsage: "A valid IPv6 address.", Proxiable: true},
+	{Name: "CAA", Data: []DataField{
+		{Name: "flags", Kind: DataUint, Usage: "Flags for the CAA record."},
+		{Name: "tag", Kind: DataString, Usage: "Name of the property controlled by this record. Allowed values: issue, issuewild, iodef"},
+		{Name: "value", Kind: DataString, Usage: "Value of the record. This field's semantics depend on the chosen tag."},
+	}},
+	{Name: "CERT", Data: []DataField{
+		{Name: "algorithm", Kind: DataUint, Usage: "Algorithm."},
+		{Name: "certificate", Kind: DataString, Usage: "Certificate."},
+		{Name: "key_tag", Kind: DataUint, Usage: "Key Tag."},
+		{Name: "type", Kind: DataUint, Usage: "Type."},
+	}},
+	{Name: "CNAME", ContentUsage: "A valid hostname. Must not match the record's name.", Proxiable: true},
+	{Name: "DNSKEY", Data: []DataField{
+		{Name: "algorithm", Kind: DataUint, Usage: "Algorithm."},
+		{Name: "flags", Kind: DataUint, Usage: "Flags."},
+		{Name: "protocol", Kind: DataUint, Usage: "Protocol."},
+		{Name: "public_key", Kind: DataString, Usage: "Public Key."},
+	}},
+	{Name: "DS", Data: []DataField{
+		{Name: "algorithm", Kind: DataUint, Usage: "Algorithm."},
+		{Name: "digest", Kind: DataString, Usage: "Digest."},
+		{Name: "digest_type", Kind: DataUint, Usage: "Digest Type."},
+		{Name: "key_tag", Kind: DataUint, Usage: "Key Tag."},
+	}},
+	{Name: "HTTPS", Data: svcbData},
+	{Name: "LOC", Data: []DataField{
+		{Name: "altitude", Kind: DataFloat, Usage: "Altitude of location in meters."},
+		{Name: "lat_degrees", Kind: DataUint, Usage: "Degrees of latitude."},
+		{Name: "lat_direction", Kind: DataString, Usage: "Latitude direction. Allowed values: N, S"},
+		{Name: "lat_minutes", Kind: DataUint, Usage: "Minutes of latitude."},
+		{Name: "lat_seconds", Kind: DataFloat, Usage: "Seconds of latitude."},
+		{Name: "long_degrees", Kind: DataUint, Usage: "Degrees of longitude."},
+		{Name: "long_direction", Kind: DataString, Usage: "Longitude direction. Allowed values: E, W"},
+		{Name: "long_minutes", Kind: DataUint, Usage: "Minutes of longitude."},
+		{Name: "long_seconds", Kind: DataFloat, Usage: "Seconds of longitude."},
+		{Name: "precision_horz", Kind: DataFloat, Usage: "Horizontal precision of location."},
+		{Name: "precision_vert", Kind: DataFloat, Usage: "Vertical precision of location."},
+		{Name: "size", Kind: DataFloat, Usage: "Size of location in meters."},
+	}},
+	{Name: "MX", ContentUsage: "A valid mail server hostname. Eg. mx.example.com", Priority: true},
+	{Name: "NAPTR", Data: []DataField{
+		{Name: "flags", Kind: DataString, Usage: "Flags."},
+		{Name: "order", Kind: DataUint, Usage: "Order."},
+		{Name: "preference", Kind: DataUint, Usage: "Preference."},
+		{Name: "regex", Kind: DataString, Usage: "Regex."},
+		{Name: "replacement", Kind: DataString, Usage: "Replacement."},
+		{Name: "service", Kind: DataString, Usage: "Service."},
+	}},
+	{Name: "NS", ContentUsage: "A valid name server host name. Eg. ns1.example.com"},
+	{Name: "PTR", ContentUsage: "Domain name pointing to the address. Eg. example.com"},
+	{Name: "SMIMEA", Data: tlsaData},
+	{Name: "SRV", Data: []DataField{
+		{Name: "port", Kind: DataUint, Usage: "The port of the service."},
+		{Name: "priority", Kind: DataUint, Usage: "Required for MX, SRV and URI records; unused by other record types. Records with lower priorities are preferred."},
+		{Name: "target", Kind: DataString, Usage: "A valid hostname."},
+		{Name: "weight", Kind: DataUint, Usage: "The record weight."},
+	}},
+	{Name: "SSHFP", Data: []DataField{
+		{Name: "algorithm", Kind: DataUint, Usage: "Algorithm."},
+		{Name: "fingerprint", Kind: DataString, Usage: "Fingerprint."},
+		{Name: "type", Kind: DataUint, Usage: "Type."},
+	}},
+	{Name: "SVCB", Data: svcbData},
+	{Name: "TLSA", Data: tlsaData},
+	{Name: "TXT", ContentUsage: "Text content for the record."},
+	{Name: "URI", Priority: true, Data: []DataField{
+		{Name: "target", Kind: DataString, Usage: "The record content."},
+		{Name: "weight", Kind: DataUint, Usage: "The record weight."},
+	}},
+}
+
+func (t RecordType) Flags(extra ...cli.Flag) []cli.Flag {
+	flags := JoinFlags(ZoneFlags, extra, []cli.Flag{
+		&cli.StringFlag{
+			Name:  "from-file",
+			Usage: "JSON or YAML file with the record, - for stdin. Flags that are given override its fields. Eg. the output of 'details --output yaml'",
+		},
+	})
+	if t.ContentUsage != "" {
+		flags = append(flags, &cli.StringFlag{
+			Name:  "content",
+			Usage: t.ContentUsage,
+		})
+	}
+	flags = append(flags,
+		&cli.StringFlag{
+			Name:  "name",
+			Usage: "DNS record name (or @ for the zone apex) in Punycode.",
+		},
+	)
+	if t.Proxiable {
+		flags = append(flags, &cli.BoolFlag{
+			Name:  "proxied",
+			Usage: "Whether the record is receiving the performance and security benefits of Cloudflare.",
+		})
+	}
+	if t.Priority {
+		flags = append(flags, &cli.Uint64Flag{
+			Name:  "priority",
+			Usage: "Required for MX, SRV and URI records; unused by other record types. Records with lower priorities are preferred.",
+		})
+	}
+	for _, field := range t.Data {
+		flags = append(flags, field.Flag())
+	}
+	return append(flags,
+		&cli.StringFlag{
+			Name:  "comment",
+			Usage: "Comments or notes about the DNS record. This field has no effect on DNS responses.",
+		},
+		&cli.StringSliceFlag{
+			Name:  "tags",
+			Usage: "Custom tags for the DNS record. This field has no effect on DNS responses.",
+		},
+		&cli.Uint64Flag{
+			Name:  "ttl",
+			Value: 1,
+			Usage: "Time To Live (TTL) of the DNS record in seconds. Setting to 1 means 'automatic'. Value must be between 60 and 86400, with the minimum reduced to 30 for Enterprise zones.",
+		},
+	)
+}
+
+func (t RecordType) Body(c *cli.Context, payload map[string]any) map[string]any {
+	tags := c.StringSlice("tags")
+	if tags == nil {
+		tags = []string{}
+	}
+	body := map[string]any{
+		"name":    c.String("name"),
+		"type":    t.Name,
+		"comment": c.String("comment"),
+		"tags":    tags,
+		"ttl":     c.Uint64("ttl"),
+	}
+	if t.ContentUsage != "" {
+		body["content"] = c.String("content")
+	}
+	if t.Proxiable {
+		body["proxied"] = c.Bool("proxied")
+	}
+	if t.Priority {
+		body["priority"] = c.Uint64("priority")
+	}
+	for key, value := range payload {
+		if key == "data" || !c.IsSet(key) {
+			body[key] = value
+		}
+	}
+	if len(t.Data) > 0 {
+		body["data"] = t.data(c, payload)
+	}
+	return body
+}
+
+// Patch is like Body but only carries the fields the user actually set. The
+// subcommand only selects the records, so the type is left as it is unless
+// --from-file gives it.
+func (t RecordType) Patch(c *cli.Context, payload map[string]any) map[string]any {
+	body := map[string]any{}
+	for key, value := range payload {
+		body[key] = value
+	}
+	for _, name := range []string{"name", "content", "comment"} {
+		if c.IsSet(name) {
+			body[name] = c.String(name)
+		}
+	}
+	if c.IsSet("proxied") {
+		body["proxied"] = c.Bool("proxied")
+	}
+	if t.Priority && c.IsSet("priority") {
+		body["priority"] = c.Uint64("priority")
+	}
+	if c.IsSet("ttl") {
+		body["ttl"] = c.Uint64("ttl")
+	}
+	if c.IsSet("tags") {
+		body["tags"] = c.StringSlice("tags")
+	}
+	if c.Bool("clear-comment") {
+		body["comment"] = ""
+	}
+	if c.Bool("clear-tags") {
+		body["tags"] = []string{}
+	}
+	if data := t.data(c, payload); len(data) > 0 {
+		body["data"] = data
+	}
+	return body
+}
+
+// data merges the data fields given by flags into those of the payload.
+func (t RecordType) data(c *cli.Context, payload map[string]any) map[string]any {
+	data := map[string]any{}
+	if fields, ok := payload["data"].(map[string]any); ok {
+		for key, value := range fields {
+			data[key] = value
+		}
+	}
+	for _, field := range t.Data {
+		if c.IsSet(field.FlagName()) {
+			data[field.Name] = field.Value(c)
+		}
+	}
+	return data
+}
+
+// readOnlyFields are set by the API, so records printed by details can be
+// used as a payload.
+var readOnlyFields = []string{"id", "zone_id", "zone_name", "proxiable", "meta", "created_on", "modified_on", "comment_modified_on", "tags_modified_on"}
+
+// recordFields are the writable fields of a record, a payload needs one.
+var recordFields = []string{"name", "type", "content", "proxied", "ttl", "priority", "data", "settings", "comment", "tags"}
+
+// Payload reads the record given by --from-file, which may be JSON or YAML,
+// and unwraps it from the envelope printed by details. Fields are checked
+// against the typed record model, fields unknown to it are sent as they are.
+func (t RecordType) Payload(c *cli.Context) (map[string]any, error) {
+	name := c.String("from-file")
+	if name == "" {
+		return nil, nil
+	}
+	raw, err := readInput(name)
+	if err != nil {
+		return nil, errors.New("failed to read record file, cause: " + err.Error())
+	}
+
+	var document any
+	if err = yaml.Unmarshal(raw, &document); err != nil {
+		return nil, errors.New("failed to parse record file, cause: " + err.Error())
+	}
+	object, err := toJSONValue(document)
+	if err != nil {
+		return nil, errors.New("failed to parse record file, cause: " + err.Error())
+	}
+	payload, ok := object.(map[string]any)
+	if _, isEnvelope := payload["result"]; ok && isEnvelope {
+		result := payload["result"]
+		if list, isList := result.([]any); isList && len(list) == 1 {
+			result = list[0]
+		}
+		payload, ok = result.(map[string]any)
+	}
+	if !ok {
+		return nil, errors.New("the record file must contain a single object")
+	}
+	for _, key := range readOnlyFields {
+		delete(payload, key)
+	}
+	if !hasAnyKey(payload, recordFields) {
+		return nil, errors.New("the record file has none of the record fields " + strings.Join(recordFields, ", "))
+	}
+
+	if recordType, ok := payload["type"]; ok && recordType != t.Name {
+		return nil, fmt.Errorf("the record file is of type %v, not %s", recordType, t.Name)
+	}
+	if raw, err = json.Marshal(payload); err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(raw, &cloudflare.DNSRecord{}); err != nil {
+		return nil, errors.New("invalid record file, cause: " + err.Error())
+	}
+	data, _ := payload["data"].(map[string]any)
+	for _, field := range t.Data {
+		value, ok := data[field.Name]
+		if !ok {
+			continue
+		}
+		number, isNumber := value.(float64)
+		switch {
+		case field.Kind == DataString && !isString(value),
+			field.Kind == DataFloat && !isNumber,
+			field.Kind == DataUint && (!isNumber || number < 0 || number != math.Trunc(number)):
+			return nil, fmt.Errorf("invalid record file, data.%s has the wrong type", field.Name)
+		}
+	}
+	return payload, nil
+}
+
+func hasAnyKey(object map[string]any, keys []string) bool {
+	for _, key := range keys {
+		if _, ok := object[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func isString(v any) bool {
+	_, ok := v.(string)
+	return ok
+}
+
+func RecordCommands(action func(t RecordType) cli.ActionFunc, extra ...cli.Flag) []*cli.Command {
+	commands := make([]*cli.Command, 0, len(RecordTypes))
+	for _, t := range RecordTypes {
+		commands = append(commands, &cli.Command{
+			Name:    t.Name,
+			Aliases: []string{strings.ToLower(t.Name)},
+			Flags:   t.Flags(extra...),
+			Action:  action(t),
+		})
+	}
+	return commands
+}
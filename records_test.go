@@ -0,0 +1,81 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestRecordTypeBodies(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want map[string]any
+	}{
+		{
+			name: "SRV",
+			args: []string{"create", "srv", "--name", "_sip._tcp", "--port", "5060", "--priority", "10", "--target", "sip.example.com", "--weight", "5"},
+			want: map[string]any{"type": "SRV", "name": "_sip._tcp",
+				"data": map[string]any{"port": float64(5060), "priority": float64(10), "target": "sip.example.com", "weight": float64(5)}},
+		},
+		{
+			name: "CAA",
+			args: []string{"create", "caa", "--name", "@", "--flags", "0", "--tag", "issue", "--value", "letsencrypt.org"},
+			want: map[string]any{"type": "CAA", "name": "@",
+				"data": map[string]any{"flags": float64(0), "tag": "issue", "value": "letsencrypt.org"}},
+		},
+		{
+			name: "LOC",
+			args: []string{"create", "loc", "--name", "office", "--lat-degrees", "52", "--lat-direction", "N", "--lat-seconds", "1.5", "--altitude", "12.25"},
+			want: map[string]any{"type": "LOC", "name": "office",
+				"data": map[string]any{"lat_degrees": float64(52), "lat_direction": "N", "lat_seconds": 1.5, "altitude": 12.25}},
+		},
+		{
+			name: "MX",
+			args: []string{"create", "mx", "--name", "@", "--content", "mx.example.com", "--priority", "10"},
+			want: map[string]any{"type": "MX", "name": "@", "content": "mx.example.com", "priority": float64(10)},
+		},
+		{
+			name: "URI",
+			args: []string{"create", "uri", "--name", "_ftp._tcp", "--priority", "10", "--target", "ftp://example.com/", "--weight", "1"},
+			want: map[string]any{"type": "URI", "name": "_ftp._tcp", "priority": float64(10),
+				"data": map[string]any{"target": "ftp://example.com/", "weight": float64(1)}},
+		},
+		{
+			name: "CERT type is data",
+			args: []string{"create", "cert", "--name", "cert", "--type", "1", "--key-tag", "2", "--algorithm", "8", "--certificate", "MIIB"},
+			want: map[string]any{"type": "CERT", "name": "cert",
+				"data": map[string]any{"type": float64(1), "key_tag": float64(2), "algorithm": float64(8), "certificate": "MIIB"}},
+		},
+		{
+			name: "SSHFP type is data",
+			args: []string{"create", "sshfp", "--name", "host", "--type", "2", "--algorithm", "4", "--fingerprint", "abcd"},
+			want: map[string]any{"type": "SSHFP", "name": "host",
+				"data": map[string]any{"type": float64(2), "algorithm": float64(4), "fingerprint": "abcd"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := runAgainstStandIn(t, append(test.args, "--zone-id", "zone")...)
+
+			if r.Method != http.MethodPost || r.Path != "/client/v4/zones/zone/dns_records" {
+				t.Errorf("sent %s %s", r.Method, r.Path)
+			}
+			for key, value := range test.want {
+				if !reflect.DeepEqual(r.Body[key], value) {
+					t.Errorf("%s = %v, want %v", key, r.Body[key], value)
+				}
+			}
+			if _, ok := test.want["content"]; !ok {
+				if _, ok := r.Body["content"]; ok {
+					t.Errorf("content sent for a data-based type: %v", r.Body)
+				}
+			}
+			if _, ok := test.want["priority"]; !ok {
+				if _, ok := r.Body["priority"]; ok {
+					t.Errorf("priority sent at the top level: %v", r.Body)
+				}
+			}
+		})
+	}
+}
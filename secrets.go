@@ -0,0 +1,225 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/zalando/go-keyring"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/term"
+	"os"
+)
+
+const (
+	SecretBackendConfig  = "config"
+	SecretBackendKeyring = "keyring"
+	SecretBackendFile    = "file"
+)
+
+// Secrets are the parts of a SecurityConfiguration that are kept out of the
+// config file by the keyring and file backends.
+type Secrets struct {
+	XAuthKey string `json:"x_auth_key,omitempty"`
+	APIToken string `json:"api_token,omitempty"`
+}
+
+type SecretStore interface {
+	Get(profile string) (*Secrets, error)
+	Set(profile string, secrets *Secrets) error
+	Delete(profile string) error
+}
+
+// OpenSecretStore opens backend, name being the path of the secrets file used
+// by the file backend.
+func OpenSecretStore(backend, name string) (SecretStore, error) {
+	switch backend {
+	case SecretBackendKeyring:
+		return &KeyringSecretStore{Service: "cf-cli"}, nil
+	case SecretBackendFile:
+		return &FileSecretStore{Path: name, Passphrase: PromptPassphrase}, nil
+	default:
+		return nil, fmt.Errorf("unknown secret backend '%s'", backend)
+	}
+}
+
+// KeyringSecretStore keeps secrets in the OS secret store, Eg. the Secret
+// Service on Linux or the Keychain on macOS.
+type KeyringSecretStore struct {
+	Service string
+}
+
+func (s *KeyringSecretStore) Get(profile string) (*Secrets, error) {
+	raw, err := keyring.Get(s.Service, profile)
+	if err != nil {
+		return nil, errors.New("failed to read secrets from the keyring, cause: " + err.Error())
+	}
+	secrets := &Secrets{}
+	if err = json.Unmarshal([]byte(raw), secrets); err != nil {
+		return nil, errors.New("failed to parse secrets from the keyring, cause: " + err.Error())
+	}
+	return secrets, nil
+}
+
+func (s *KeyringSecretStore) Set(profile string, secrets *Secrets) error {
+	raw, err := json.Marshal(secrets)
+	if err != nil {
+		return err
+	}
+	if err = keyring.Set(s.Service, profile, string(raw)); err != nil {
+		return errors.New("failed to write secrets to the keyring, use --secret-backend file where there is none, cause: " + err.Error())
+	}
+	return nil
+}
+
+func (s *KeyringSecretStore) Delete(profile string) error {
+	if err := keyring.Delete(s.Service, profile); err != nil && !errors.Is(err, keyring.ErrNotFound) {
+		return errors.New("failed to delete secrets from the keyring, cause: " + err.Error())
+	}
+	return nil
+}
+
+// FileSecretStore keeps the secrets of every profile in a single file,
+// encrypted with AES-GCM under a key derived from a passphrase by scrypt.
+type FileSecretStore struct {
+	Path       string
+	Passphrase func() (string, error)
+}
+
+type encryptedSecrets struct {
+	Salt  []byte `json:"salt"`
+	Nonce []byte `json:"nonce"`
+	Data  []byte `json:"data"`
+}
+
+func (s *FileSecretStore) Get(profile string) (*Secrets, error) {
+	all, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	secrets, ok := all[profile]
+	if !ok {
+		return nil, fmt.Errorf("no secrets stored for profile '%s'", profile)
+	}
+	return secrets, nil
+}
+
+func (s *FileSecretStore) Set(profile string, secrets *Secrets) error {
+	all, err := s.load()
+	if err != nil {
+		return err
+	}
+	all[profile] = secrets
+	return s.save(all)
+}
+
+func (s *FileSecretStore) Delete(profile string) error {
+	all, err := s.load()
+	if err != nil {
+		return err
+	}
+	delete(all, profile)
+	return s.save(all)
+}
+
+func (s *FileSecretStore) load() (map[string]*Secrets, error) {
+	all := map[string]*Secrets{}
+	raw, err := os.ReadFile(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return all, nil
+	} else if err != nil {
+		return nil, errors.New("failed to open secrets file, cause: " + err.Error())
+	}
+
+	encrypted := &encryptedSecrets{}
+	if err = json.Unmarshal(raw, encrypted); err != nil {
+		return nil, errors.New("failed to parse secrets file, cause: " + err.Error())
+	}
+	aead, err := s.cipher(encrypted.Salt)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := aead.Open(nil, encrypted.Nonce, encrypted.Data, nil)
+	if err != nil {
+		return nil, errors.New("failed to decrypt secrets file, wrong passphrase?")
+	}
+	if err = json.Unmarshal(plain, &all); err != nil {
+		return nil, errors.New("failed to parse secrets file, cause: " + err.Error())
+	}
+	return all, nil
+}
+
+func (s *FileSecretStore) save(all map[string]*Secrets) error {
+	plain, err := json.Marshal(all)
+	if err != nil {
+		return err
+	}
+	encrypted := &encryptedSecrets{Salt: make([]byte, 16)}
+	if _, err = rand.Read(encrypted.Salt); err != nil {
+		return err
+	}
+	aead, err := s.cipher(encrypted.Salt)
+	if err != nil {
+		return err
+	}
+	encrypted.Nonce = make([]byte, aead.NonceSize())
+	if _, err = rand.Read(encrypted.Nonce); err != nil {
+		return err
+	}
+	encrypted.Data = aead.Seal(nil, encrypted.Nonce, plain, nil)
+
+	f, err := ShouldOpenFile(s.Path, os.O_WRONLY|os.O_TRUNC|os.O_CREATE, 0600)
+	if err != nil {
+		return errors.New("failed to open secrets file, cause: " + err.Error())
+	}
+	defer f.Close()
+	if err = f.Chmod(0600); err != nil {
+		return err
+	}
+	return json.NewEncoder(f).Encode(encrypted)
+}
+
+func (s *FileSecretStore) cipher(salt []byte) (cipher.AEAD, error) {
+	passphrase, err := s.Passphrase()
+	if err != nil {
+		return nil, err
+	}
+	key, err := scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+var passphrase string
+
+// PromptPassphrase reads the passphrase of the secrets file from
+// CF_CLI_PASSPHRASE, or asks for it once when running in a terminal.
+func PromptPassphrase() (string, error) {
+	if passphrase != "" {
+		return passphrase, nil
+	}
+	if passphrase = os.Getenv("CF_CLI_PASSPHRASE"); passphrase != "" {
+		return passphrase, nil
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", errors.New("the secrets file needs a passphrase, set CF_CLI_PASSPHRASE")
+	}
+	fmt.Fprint(os.Stderr, "Passphrase of the secrets file: ")
+	raw, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", errors.New("failed to read passphrase, cause: " + err.Error())
+	}
+	if len(raw) == 0 {
+		return "", errors.New("the passphrase must not be empty")
+	}
+	passphrase = string(raw)
+	return passphrase, nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileSecretStore(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "secrets")
+	store := &FileSecretStore{Path: name, Passphrase: func() (string, error) { return "passphrase", nil }}
+
+	if err := store.Set("prod", &Secrets{APIToken: "api-token-value"}); err != nil {
+		t.Fatalf("failed to set secrets: %s", err)
+	}
+	if err := store.Set("staging", &Secrets{XAuthKey: "key"}); err != nil {
+		t.Fatalf("failed to set secrets: %s", err)
+	}
+
+	raw, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatalf("failed to read secrets file: %s", err)
+	}
+	if strings.Contains(string(raw), "api-token-value") {
+		t.Errorf("secrets file contains the token in plaintext")
+	}
+	info, err := os.Stat(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("secrets file mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	secrets, err := store.Get("prod")
+	if err != nil || secrets.APIToken != "api-token-value" {
+		t.Fatalf("Get(prod) = %v, %v", secrets, err)
+	}
+
+	if err = store.Delete("prod"); err != nil {
+		t.Fatalf("failed to delete secrets: %s", err)
+	}
+	if _, err = store.Get("prod"); err == nil {
+		t.Errorf("Get(prod) after Delete succeeded")
+	}
+
+	wrong := &FileSecretStore{Path: name, Passphrase: func() (string, error) { return "wrong", nil }}
+	if _, err = wrong.Get("staging"); err == nil {
+		t.Errorf("Get with a wrong passphrase succeeded")
+	}
+}
+
+func TestSetupForgetsPreviousSecretsAfterSaving(t *testing.T) {
+	isolate(t)
+	t.Setenv("CF_CLI_PASSPHRASE", "passphrase")
+	s := newStandIn(t, nil)
+	setup := func(backend string) *Handler {
+		t.Helper()
+		handler := &Handler{}
+		if _, err := s.run(t, handler, "setup", "--skip-verify", "--api-token", "token-"+backend, "--secret-backend", backend); err != nil {
+			t.Fatalf("setup with %s backend failed: %s", backend, err)
+		}
+		return handler
+	}
+
+	handler := setup(SecretBackendFile)
+	store, err := handler.File.SecretStore(SecretBackendFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secrets, err := store.Get(DefaultProfile); err != nil || secrets.APIToken != "token-file" {
+		t.Fatalf("Get(%s) = %v, %v", DefaultProfile, secrets, err)
+	}
+
+	handler = setup(SecretBackendConfig)
+	if token := handler.File.Profiles[DefaultProfile].APIToken; token != "token-config" {
+		t.Errorf("saved token = %q, want token-config", token)
+	}
+	if _, err := store.Get(DefaultProfile); err == nil {
+		t.Errorf("secrets of the previous backend were kept")
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/urfave/cli/v2"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Settings are the defaults 'config set' accepts, each validating its value.
+var Settings = map[string]func(value string) error{
+	"zone": func(value string) error {
+		if normalizeZoneName(value) == "" {
+			return errors.New("the zone must not be empty")
+		}
+		return nil
+	},
+	"output":   validateOutputFormat,
+	"api-base": validateBaseURL,
+	"retries": func(value string) error {
+		if retries, err := strconv.Atoi(value); err != nil || retries < 0 {
+			return fmt.Errorf("invalid number of retries '%s'", value)
+		}
+		return nil
+	},
+	"rate-limit": func(value string) error {
+		if rate, err := strconv.ParseFloat(value, 64); err != nil || rate < 0 {
+			return fmt.Errorf("invalid rate limit '%s', Eg. 4", value)
+		}
+		return nil
+	},
+	"timeout": func(value string) error {
+		if timeout, err := time.ParseDuration(value); err != nil || timeout < 0 {
+			return fmt.Errorf("invalid timeout '%s', Eg. 30s", value)
+		}
+		return nil
+	},
+}
+
+func settingKeys() string {
+	keys := make([]string, 0, len(Settings))
+	for key := range Settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ", ")
+}
+
+func settingKey(c *cli.Context) (string, error) {
+	key := c.Args().First()
+	if _, ok := Settings[key]; !ok {
+		return "", fmt.Errorf("unknown setting '%s', allowed values: %s", key, settingKeys())
+	}
+	if c.Bool("in-profile") && key != "zone" {
+		return "", fmt.Errorf("setting '%s' cannot be stored in a profile, only zone can", key)
+	}
+	return key, nil
+}
+
+var SettingFlags = []cli.Flag{
+	&cli.BoolFlag{
+		Name:  "in-profile",
+		Usage: "Use the setting of the selected profile instead of the one shared by every profile, only for zone",
+	},
+}
+
+func (h *Handler) ConfigPath(c *cli.Context) error {
+	_, err := fmt.Println(h.File.Path)
+	return err
+}
+
+func (h *Handler) GetSetting(c *cli.Context) error {
+	key, err := settingKey(c)
+	if err != nil {
+		return err
+	}
+	value, ok := h.File.Defaults[key]
+	if c.Bool("in-profile") {
+		profile, err := h.File.Profile(h.Profile)
+		if err != nil {
+			return err
+		}
+		value, ok = profile.Zone, profile.Zone != ""
+	}
+	if !ok {
+		return fmt.Errorf("setting '%s' is not set", key)
+	}
+	_, err = fmt.Println(value)
+	return err
+}
+
+func (h *Handler) SetSetting(c *cli.Context) error {
+	key, err := settingKey(c)
+	if err != nil {
+		return err
+	}
+	if c.NArg() != 2 {
+		return &UsageError{Err: errors.New("usage: cf-cli config set KEY VALUE")}
+	}
+	value := c.Args().Get(1)
+	if err = Settings[key](value); err != nil {
+		return err
+	}
+	if c.Bool("in-profile") {
+		profile, err := h.File.Profile(h.Profile)
+		if err != nil {
+			return err
+		}
+		profile.Zone = value
+		return h.File.Save()
+	}
+	if h.File.Defaults == nil {
+		h.File.Defaults = map[string]string{}
+	}
+	h.File.Defaults[key] = value
+	return h.File.Save()
+}
+
+func (h *Handler) UnsetSetting(c *cli.Context) error {
+	key, err := settingKey(c)
+	if err != nil {
+		return err
+	}
+	if c.Bool("in-profile") {
+		profile, err := h.File.Profile(h.Profile)
+		if err != nil {
+			return err
+		}
+		profile.Zone = ""
+		return h.File.Save()
+	}
+	delete(h.File.Defaults, key)
+	return h.File.Save()
+}
+
+const redacted = "REDACTED"
+
+// ShowConfig prints the config file with the auth keys and API tokens
+// replaced by REDACTED.
+func (h *Handler) ShowConfig(c *cli.Context) error {
+	output, err := NewOutput(c)
+	if err != nil {
+		return err
+	}
+
+	file := &ConfigFile{
+		CurrentProfile: h.File.CurrentProfile,
+		Profiles:       make(map[string]*SecurityConfiguration, len(h.File.Profiles)),
+		Defaults:       h.File.Defaults,
+	}
+	for name, profile := range h.File.Profiles {
+		shown := *profile
+		if shown.XAuthKey != "" {
+			shown.XAuthKey = redacted
+		}
+		if shown.APIToken != "" {
+			shown.APIToken = redacted
+		}
+		file.Profiles[name] = &shown
+	}
+	return output.Value(file)
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestShowConfigRedactsSecrets(t *testing.T) {
+	isolate(t)
+	name := filepath.Join(t.TempDir(), "config.json")
+	file, _ := OpenConfigFile(name)
+	file.CurrentProfile = "token"
+	file.Profiles["token"] = &SecurityConfiguration{APIToken: "secret-api-token", Zone: "example.com"}
+	file.Profiles["key"] = &SecurityConfiguration{XAuthEmail: "someone@example.com", XAuthKey: "secret-auth-key"}
+	file.Profiles["keyring"] = &SecurityConfiguration{XAuthEmail: "other@example.com", SecretBackend: SecretBackendKeyring}
+	if err := file.Save(); err != nil {
+		t.Fatal(err)
+	}
+	s := newStandIn(t, nil)
+
+	for _, output := range []string{"json", "yaml"} {
+		stdout, err := s.run(t, nil, "--config", name, "config", "show", "--output", output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, secret := range []string{"secret-api-token", "secret-auth-key"} {
+			if strings.Contains(stdout, secret) {
+				t.Errorf("%s output contains %q:\n%s", output, secret, stdout)
+			}
+		}
+		for _, want := range []string{"someone@example.com", "example.com", "keyring"} {
+			if !strings.Contains(stdout, want) {
+				t.Errorf("%s output lacks %q:\n%s", output, want, stdout)
+			}
+		}
+		if strings.Count(stdout, redacted) != 2 {
+			t.Errorf("%s output redacts %d values, want 2:\n%s", output, strings.Count(stdout, redacted), stdout)
+		}
+	}
+	if reopened, _ := OpenConfigFile(name); reopened.Profiles["token"].APIToken != "secret-api-token" {
+		t.Errorf("showing the config changed the file")
+	}
+}
+
+// saveConfig writes the config file the CLI finds by default.
+func saveConfig(t *testing.T, defaults map[string]string, profiles map[string]*SecurityConfiguration) string {
+	t.Helper()
+	name, err := ConfigFilePath("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.MkdirAll(filepath.Dir(name), 0700); err != nil {
+		t.Fatal(err)
+	}
+	file := &ConfigFile{CurrentProfile: DefaultProfile, Profiles: profiles, Defaults: defaults, Path: name}
+	if err = file.Save(); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+func TestZonePrecedence(t *testing.T) {
+	const id = "023e105f4ecef8ad9ca31a8372d0c353"
+	tests := []struct {
+		name        string
+		args        []string
+		profileZone string
+		sharedZone  string
+		want        string
+	}{
+		{"zone id flag", []string{"--zone-id", "zone-id", "--zone", "flag.com"}, "profile.com", "shared.com", "zone-id"},
+		{"zone flag", []string{"--zone", "flag.com"}, "profile.com", "shared.com", "flag"},
+		{"zone flag with an identifier", []string{"--zone", id}, "profile.com", "shared.com", id},
+		{"profile zone", nil, "profile.com", "shared.com", "profile"},
+		{"shared zone", nil, "", "shared.com", "shared"},
+		{"shared zone with an identifier", nil, "", id, id},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			isolate(t)
+			s := zoneStandIn(t, map[string][]string{"flag.com": {"flag"}, "profile.com": {"profile"}, "shared.com": {"shared"}, "": {id, "zone-id"}})
+			profile := &SecurityConfiguration{APIToken: "token", Zone: test.profileZone}
+			saveConfig(t, map[string]string{"zone": test.sharedZone}, map[string]*SecurityConfiguration{DefaultProfile: profile})
+
+			_, err := s.run(t, &Handler{}, append([]string{"details", "--record-id", "record"}, test.args...)...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, paths := zoneRequests(s)
+			if want := "/client/v4/zones/" + test.want + "/dns_records/record"; len(paths) != 1 || paths[0] != want {
+				t.Errorf("requested %v, want %s", paths, want)
+			}
+		})
+	}
+
+	isolate(t)
+	s := zoneStandIn(t, nil)
+	saveConfig(t, nil, map[string]*SecurityConfiguration{DefaultProfile: {APIToken: "token"}})
+	if _, err := s.run(t, &Handler{}, "details", "--record-id", "record"); err == nil || !strings.HasPrefix(err.Error(), "no zone given") {
+		t.Errorf("without a zone: err = %v", err)
+	}
+}
+
+func TestSettingsInProfile(t *testing.T) {
+	isolate(t)
+	s := newStandIn(t, nil)
+	name := saveConfig(t, nil, map[string]*SecurityConfiguration{
+		DefaultProfile: {APIToken: "token"},
+		"prod":         {APIToken: "prod-token", Zone: "prod.com"},
+	})
+	run := func(args ...string) (string, error) {
+		t.Helper()
+		stdout, err := s.run(t, &Handler{}, args...)
+		return strings.TrimSpace(stdout), err
+	}
+
+	if _, err := run("config", "set", "zone", "shared.com"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := run("config", "set", "--in-profile", "zone", "default.com"); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"config", "get", "zone"}, "shared.com"},
+		{[]string{"config", "get", "--in-profile", "zone"}, "default.com"},
+		{[]string{"--profile", "prod", "config", "get", "--in-profile", "zone"}, "prod.com"},
+	} {
+		if got, err := run(test.args...); err != nil || got != test.want {
+			t.Errorf("%v = %q, %v, want %q", test.args, got, err, test.want)
+		}
+	}
+
+	file, _ := OpenConfigFile(name)
+	if file.Defaults["zone"] != "shared.com" || file.Profiles[DefaultProfile].Zone != "default.com" || file.Profiles["prod"].Zone != "prod.com" {
+		t.Errorf("saved defaults %v, profile zones %q and %q", file.Defaults, file.Profiles[DefaultProfile].Zone, file.Profiles["prod"].Zone)
+	}
+
+	if _, err := run("config", "unset", "--in-profile", "zone"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := run("config", "get", "--in-profile", "zone"); err == nil || err.Error() != "setting 'zone' is not set" {
+		t.Errorf("unset profile zone: err = %v", err)
+	}
+	if got, err := run("config", "get", "zone"); err != nil || got != "shared.com" {
+		t.Errorf("unsetting the profile zone changed the shared one to %q, %v", got, err)
+	}
+
+	for _, args := range [][]string{
+		{"config", "set", "--in-profile", "output", "table"},
+		{"config", "set", "output", "xml"},
+		{"config", "set", "owner", "me"},
+		{"--profile", "missing", "config", "set", "--in-profile", "zone", "example.com"},
+	} {
+		if _, err := run(args...); err == nil {
+			t.Errorf("%v succeeded", args)
+		}
+	}
+}
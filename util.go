@@ -1,187 +1,206 @@
 package main
 
 import (
-	"bytes"
+	"cf-cli/cloudflare"
+	"context"
 	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
+	"github.com/urfave/cli/v2"
 	"io"
-	"net/http"
 	"os"
-	"path"
-	"path/filepath"
 	"strings"
 )
 
-type MessageError struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
+const (
+	ExitFailure = iota + 1
+	ExitUsage
+	ExitAuth
+	ExitNotFound
+	ExitValidation
+	ExitRateLimit
+	ExitNetwork
+
+	// ExitInterrupted follows the shell convention of 128 + SIGINT.
+	ExitInterrupted = 130
+)
+
+func ExitCode(err error) int {
+	var apiErr *cloudflare.Error
+	var networkErr *cloudflare.NetworkError
+	var usageErr *UsageError
+	switch {
+	case errors.Is(err, context.Canceled):
+		return ExitInterrupted
+	case errors.Is(err, ErrUnready):
+		return ExitAuth
+	case errors.As(err, &usageErr):
+		return ExitUsage
+	case errors.As(err, &networkErr):
+		return ExitNetwork
+	case errors.Is(err, cloudflare.ErrNotFound):
+		return ExitNotFound
+	case !errors.As(err, &apiErr):
+		return ExitFailure
+	case apiErr.IsAuth():
+		return ExitAuth
+	case apiErr.IsNotFound():
+		return ExitNotFound
+	case apiErr.IsRateLimited():
+		return ExitRateLimit
+	case apiErr.IsValidation():
+		return ExitValidation
+	default:
+		return ExitFailure
+	}
 }
 
-type Message struct {
-	Success bool           `json:"success"`
-	Errors  []MessageError `json:"errors"`
+// UsageError is returned for invalid flags and arguments.
+type UsageError struct {
+	Err error
 }
 
-func FileExist(name string) bool {
-	_, err := os.Stat(name)
-	return err == nil || os.IsExist(err)
+func (e *UsageError) Error() string {
+	return e.Err.Error()
 }
 
-func ShouldOpenFile(name string, flag int, perm os.FileMode) (*os.File, error) {
-	dir := name[0 : strings.LastIndex(name, "/")+1]
-	if !FileExist(dir) {
-		if err := os.MkdirAll(dir, perm); err != nil {
-			return nil, err
-		}
-	}
-	return os.OpenFile(name, flag, perm)
+func (e *UsageError) Unwrap() error {
+	return e.Err
 }
 
-func FailPrintf(format string, a ...any) {
-	json.NewEncoder(os.Stdout).Encode(Message{
-		Success: false,
-		Errors: []MessageError{
-			{Code: 0, Message: fmt.Sprintf(format, a...)},
-		},
-	})
+// onUsageError points at the help of the command instead of printing it on
+// stdout.
+func onUsageError(c *cli.Context, err error, isSubcommand bool) error {
+	return &UsageError{Err: fmt.Errorf("%s, see '%s --help'", err, c.Command.HelpName)}
 }
 
-type SecurityConfiguration struct {
-	XAuthEmail string `json:"x_auth_email,omitempty"`
-	XAuthKey   string `json:"x_auth_key,omitempty"`
-	APIToken   string `json:"api_token,omitempty"`
+type MessageError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
 }
 
-func (c *SecurityConfiguration) Save() error {
-	dir, err := os.UserHomeDir()
-	if err != nil {
-		return errors.New("failed to get user home dir, cause: " + err.Error())
-	}
-	f, err := ShouldOpenFile(filepath.Join(dir, ".cf_cli_config"), os.O_WRONLY|os.O_TRUNC|os.O_CREATE, 0644)
-	if err != nil {
-		return errors.New("failed to open security configuration file, cause: " + err.Error())
-	}
-	defer f.Close()
-	return json.NewEncoder(f).Encode(c)
+type Message struct {
+	Success bool           `json:"success"`
+	Errors  []MessageError `json:"errors"`
 }
 
-func OpenSecurityConfiguration() (*SecurityConfiguration, error) {
-	dir, err := os.UserHomeDir()
-	if err != nil {
-		return nil, errors.New("failed to get user home dir, cause: " + err.Error())
+// readInput reads the file name, or stdin when name is -.
+func readInput(name string) ([]byte, error) {
+	if name == "-" {
+		return io.ReadAll(os.Stdin)
 	}
-	f, err := ShouldOpenFile(filepath.Join(dir, ".cf_cli_config"), os.O_RDONLY, 0644)
-	if err != nil {
-		return nil, errors.New("failed to open security configuration, cause: " + err.Error())
-	}
-	defer f.Close()
-	var c SecurityConfiguration
-	if err = json.NewDecoder(f).Decode(&c); err != nil {
-		return nil, errors.New("failed to parse security configuration, cause: " + err.Error())
-	}
-	return &c, nil
+	return os.ReadFile(name)
 }
 
-type RequestOption func(r *http.Request)
-
-func UsePathParameters(name, value string) RequestOption {
-	return func(r *http.Request) {
-		r.URL.Path = strings.ReplaceAll(r.URL.Path, fmt.Sprintf("{%s}", name), value)
-	}
+// recordedFlag collects the values given to a flag by interspersedArgs.
+type recordedFlag struct {
+	name   string
+	isBool bool
+	values *[][2]string
 }
 
-func UseQueryParameters(key, value string) RequestOption {
-	return func(r *http.Request) {
-		if key == "" || value == "" {
-			return
-		}
-		query := r.URL.Query()
-		query.Set(key, value)
-		r.URL.RawQuery = query.Encode()
-	}
+func (f recordedFlag) String() string   { return "" }
+func (f recordedFlag) IsBoolFlag() bool { return f.isBool }
+
+func (f recordedFlag) Set(value string) error {
+	*f.values = append(*f.values, [2]string{f.name, value})
+	return nil
 }
 
-func UseQueryParametersWithMap(sets map[string]string) RequestOption {
-	return func(r *http.Request) {
-		query := r.URL.Query()
-		for key, value := range sets {
-			if key == "" || value == "" {
-				continue
-			}
-			query.Set(key, value)
+// interspersedArgs applies the flags of the command that follow its
+// positional arguments, which urfave/cli leaves among the arguments, and
+// returns the positional arguments alone.
+func interspersedArgs(c *cli.Context) ([]string, error) {
+	var values [][2]string
+	set := flag.NewFlagSet(c.Command.Name, flag.ContinueOnError)
+	set.SetOutput(io.Discard)
+	for _, f := range c.Command.Flags {
+		_, isBool := f.(*cli.BoolFlag)
+		for _, name := range f.Names() {
+			set.Var(recordedFlag{name: f.Names()[0], isBool: isBool, values: &values}, name, "")
 		}
-		r.URL.RawQuery = query.Encode()
 	}
-}
 
-func UseSecurity(c *SecurityConfiguration) RequestOption {
-	return func(r *http.Request) {
-		if c.XAuthEmail != "" && c.XAuthKey != "" {
-			r.Header.Set("X-Auth-Email", c.XAuthEmail)
-			r.Header.Set("X-Auth-Key", c.XAuthKey)
+	var positional []string
+	for args := c.Args().Slice(); len(args) > 0; {
+		if err := set.Parse(args); err != nil {
+			return nil, onUsageError(c, err, true)
 		}
-
-		if c.APIToken != "" {
-			r.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.APIToken))
+		if args = set.Args(); len(args) > 0 {
+			positional = append(positional, args[0])
+			args = args[1:]
 		}
 	}
+	for _, value := range values {
+		if err := c.Set(value[0], value[1]); err != nil {
+			return nil, &UsageError{Err: fmt.Errorf("invalid value '%s' for --%s, cause: %s", value[1], value[0], err)}
+		}
+	}
+	return positional, nil
+}
+
+func FileExist(name string) bool {
+	_, err := os.Stat(name)
+	return err == nil || os.IsExist(err)
 }
 
-func UseBody(body io.Reader) RequestOption {
-	return func(r *http.Request) {
-		rc, ok := body.(io.ReadCloser)
-		if !ok {
-			rc = io.NopCloser(body)
+func ShouldOpenFile(name string, flag int, perm os.FileMode) (*os.File, error) {
+	dir := name[0 : strings.LastIndex(name, "/")+1]
+	if !FileExist(dir) {
+		if err := os.MkdirAll(dir, perm|0700); err != nil {
+			return nil, err
 		}
-		r.Body = rc
 	}
+	return os.OpenFile(name, flag, perm)
 }
 
-func UseJSONBody(v any) RequestOption {
-	buf := &bytes.Buffer{}
-	json.NewEncoder(buf).Encode(v)
-	return func(r *http.Request) {
-		r.Header.Set("Content-Type", "application/json")
-		r.Body = io.NopCloser(buf)
+func JoinFlags(groups ...[]cli.Flag) []cli.Flag {
+	var flags []cli.Flag
+	for _, group := range groups {
+		flags = append(flags, group...)
 	}
+	return flags
 }
 
-func Request(method string, api string, opts ...RequestOption) {
-	request, err := http.NewRequest(method, path.Join(BaseAPI, api), nil)
-	if err != nil {
-		FailPrintf("failed to NewRequest, cause: %s", err)
-		return
-	}
+func PrintResult(result any, info *cloudflare.ResultInfo) error {
+	return WriteResult(os.Stdout, result, info)
+}
 
-	for _, opt := range opts {
-		opt(request)
-	}
+// WriteResult writes result to w in the response envelope of the API.
+func WriteResult(w io.Writer, result any, info *cloudflare.ResultInfo) error {
+	return json.NewEncoder(w).Encode(map[string]any{
+		"success":     true,
+		"errors":      []any{},
+		"messages":    []any{},
+		"result":      result,
+		"result_info": info,
+	})
+}
 
-	response, err := http.DefaultClient.Do(request)
-	if err != nil {
-		FailPrintf("failed to request, cause: %s", err)
+func PrintError(err error) {
+	var apiErr *cloudflare.Error
+	if !errors.As(err, &apiErr) || len(apiErr.Errors) == 0 {
+		FailPrintf("%s", err)
 		return
 	}
-	defer response.Body.Close()
-
-	if response.Header.Get("Content-Type") == "text/plain" {
-		body, err := io.ReadAll(response.Body)
-		if err != nil {
-			FailPrintf("failed to read response body, cause: %s", err)
-			return
-		}
-		json.NewEncoder(os.Stdout).Encode(map[string]any{
-			"success": true,
-			"errors":  []any{},
-			"result":  string(body),
-		})
-		return
+	message := Message{Success: false}
+	for _, info := range apiErr.Errors {
+		message.Errors = append(message.Errors, MessageError{Code: info.Code, Message: info.Message})
 	}
+	json.NewEncoder(os.Stderr).Encode(message)
+}
 
-	_, err = io.Copy(os.Stdout, response.Body)
-	if err != nil {
-		FailPrintf("failed to copy response body, cause: %s", err)
-	}
-	return
+// Logf writes a diagnostic line to stderr.
+func Logf(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+}
+
+func FailPrintf(format string, a ...any) {
+	json.NewEncoder(os.Stderr).Encode(Message{
+		Success: false,
+		Errors: []MessageError{
+			{Code: 0, Message: fmt.Sprintf(format, a...)},
+		},
+	})
 }
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"cf-cli/cloudflare"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	apiError := func(status int, codes ...int) error {
+		err := &cloudflare.Error{StatusCode: status}
+		for _, code := range codes {
+			err.Errors = append(err.Errors, cloudflare.ResponseInfo{Code: code, Message: "failed"})
+		}
+		return err
+	}
+	tests := []struct {
+		name string
+		err  error
+		code int
+	}{
+		{"unauthorized", apiError(http.StatusUnauthorized), ExitAuth},
+		{"forbidden", apiError(http.StatusForbidden), ExitAuth},
+		{"invalid token code", apiError(http.StatusBadRequest, 6003), ExitAuth},
+		{"not found", apiError(http.StatusNotFound), ExitNotFound},
+		{"invalid zone code", apiError(http.StatusBadRequest, 7003), ExitNotFound},
+		{"bad request", apiError(http.StatusBadRequest, 9005), ExitValidation},
+		{"unprocessable", apiError(http.StatusUnprocessableEntity), ExitValidation},
+		{"too many requests", apiError(http.StatusTooManyRequests), ExitRateLimit},
+		{"rate limit code", apiError(http.StatusOK, 971), ExitRateLimit},
+		{"server error", apiError(http.StatusInternalServerError), ExitFailure},
+		{"wrapped api error", fmt.Errorf("failed to list: %w", apiError(http.StatusNotFound)), ExitNotFound},
+		{"lookup without match", fmt.Errorf("zone 'example.com': %w", cloudflare.ErrNotFound), ExitNotFound},
+		{"network", &cloudflare.NetworkError{Err: errors.New("connection refused")}, ExitNetwork},
+		{"unready", ErrUnready, ExitAuth},
+		{"canceled", context.Canceled, ExitInterrupted},
+		{"canceled request", &cloudflare.NetworkError{Err: context.Canceled}, ExitInterrupted},
+		{"canceled batch", fmt.Errorf("stopped after deleting 1 of 2 records: %w", context.Canceled), ExitInterrupted},
+		{"usage", &UsageError{Err: errors.New("flag provided but not defined: -bogus")}, ExitUsage},
+		{"other", errors.New("failed"), ExitFailure},
+	}
+	for _, test := range tests {
+		if code := ExitCode(test.err); code != test.code {
+			t.Errorf("%s: ExitCode(%v) = %d, want %d", test.name, test.err, code, test.code)
+		}
+	}
+}
+
+func TestUsageErrors(t *testing.T) {
+	isolate(t)
+	s := newStandIn(t, nil)
+	for _, args := range [][]string{
+		{"list", "--zone-id", "zone", "--bogus"},
+		{"create", "a", "--zone-id", "zone", "--ttl", "abc"},
+		{"--bogus", "list"},
+		{"api", "GET"},
+		{"api", "GET", "/zones", "--bogus"},
+		{"config", "set", "zone"},
+	} {
+		stdout, err := s.run(t, nil, args...)
+		if ExitCode(err) != ExitUsage {
+			t.Errorf("%v: exit code %d, want %d, err %v", args, ExitCode(err), ExitUsage, err)
+		}
+		if stdout != "" {
+			t.Errorf("%v printed %q on stdout", args, stdout)
+		}
+	}
+	if len(s.Requests()) != 0 {
+		t.Errorf("usage errors sent requests")
+	}
+}
+
+func TestPrintErrorKeepsPercentSigns(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr := os.Stderr
+	os.Stderr = w
+	PrintError(errors.New("GET /zones?comment.contains=a+b%2Cc: 100% failed"))
+	os.Stderr = stderr
+	w.Close()
+
+	var message Message
+	if err = json.NewDecoder(r).Decode(&message); err != nil {
+		t.Fatal(err)
+	}
+	if len(message.Errors) != 1 || message.Errors[0].Message != "GET /zones?comment.contains=a+b%2Cc: 100% failed" {
+		t.Errorf("printed %+v", message)
+	}
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"cf-cli/cloudflare"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/urfave/cli/v2"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ZoneFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:  "zone-id",
+		Usage: "Identifier, <= 32 characters, Eg. 023e105f4ecef8ad9ca31a8372d0c353",
+	},
+	&cli.StringFlag{
+		Name:  "zone",
+		Usage: "Domain name of the zone, used when --zone-id is not given. Eg. example.com",
+	},
+}
+
+// zoneCacheTTL is how long a resolved zone is used before it is looked up
+// again.
+const zoneCacheTTL = 24 * time.Hour
+
+type ZoneCacheEntry struct {
+	ID       string    `json:"id"`
+	Resolved time.Time `json:"resolved"`
+}
+
+// ZoneCache maps zone names to identifiers so --zone is not resolved on every
+// call. Keys also hold the API base, profile and a hash of the credentials
+// the zone was resolved with, as the same name may be another zone elsewhere.
+type ZoneCache map[string]ZoneCacheEntry
+
+func (z ZoneCache) Get(key string) (string, bool) {
+	entry, ok := z[key]
+	if !ok || entry.ID == "" || time.Since(entry.Resolved) > zoneCacheTTL {
+		return "", false
+	}
+	return entry.ID, true
+}
+
+func (z ZoneCache) Set(key, id string) {
+	z[key] = ZoneCacheEntry{ID: id, Resolved: time.Now()}
+}
+
+func zoneCachePath() (string, error) {
+	dir, err := xdgDir("XDG_CACHE_HOME", os.UserCacheDir)
+	if err != nil {
+		return "", errors.New("failed to get user cache dir, cause: " + err.Error())
+	}
+	return filepath.Join(dir, "cf-cli", "zones.json"), nil
+}
+
+func OpenZoneCache() ZoneCache {
+	cache := ZoneCache{}
+	name, err := zoneCachePath()
+	if err != nil {
+		return cache
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return cache
+	}
+	defer f.Close()
+	json.NewDecoder(f).Decode(&cache)
+	return cache
+}
+
+func (z ZoneCache) Save() error {
+	name, err := zoneCachePath()
+	if err != nil {
+		return err
+	}
+	for key := range z {
+		if _, ok := z.Get(key); !ok {
+			delete(z, key)
+		}
+	}
+	f, err := ShouldOpenFile(name, os.O_WRONLY|os.O_TRUNC|os.O_CREATE, 0600)
+	if err != nil {
+		return errors.New("failed to open zone cache file, cause: " + err.Error())
+	}
+	defer f.Close()
+	return json.NewEncoder(f).Encode(z)
+}
+
+func normalizeZoneName(name string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(name)), ".")
+}
+
+func isZoneID(s string) bool {
+	if len(s) != 32 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+// zoneID resolves --zone-id, then --zone, then the zone of the profile and
+// last the one stored by 'config set zone'. Stored zones may be identifiers.
+// The zone is returned as cached as well when it was taken from the cache.
+func (h *Handler) zoneID(c *cli.Context) (string, *cachedZone, error) {
+	if id := c.String("zone-id"); id != "" {
+		return id, nil, nil
+	}
+	name := c.String("zone")
+	if name == "" && h.Configuration != nil {
+		name = h.Configuration.Zone
+	}
+	if name == "" {
+		name = h.File.Defaults["zone"]
+	}
+	if isZoneID(name) {
+		return name, nil, nil
+	}
+	name = normalizeZoneName(name)
+	if name == "" {
+		return "", nil, errors.New("no zone given, pass --zone-id or --zone, or store a default with 'cf-cli config set zone NAME'")
+	}
+
+	key := h.zoneCacheKey(name)
+	cache := OpenZoneCache()
+	if id, ok := cache.Get(key); ok {
+		return id, &cachedZone{Key: key, Name: name, ID: id}, nil
+	}
+	id, err := h.resolveZone(c, cache, key, name)
+	return id, nil, err
+}
+
+// cachedZone is the zone a command took from the cache.
+type cachedZone struct {
+	Key, Name, ID string
+}
+
+func (h *Handler) zoneCacheKey(name string) string {
+	credentials := h.Client.Credentials
+	sum := sha256.Sum256([]byte(credentials.APIToken + "\x00" + credentials.XAuthEmail + "\x00" + credentials.XAuthKey))
+	return strings.Join([]string{h.Client.BaseURL, h.Profile, hex.EncodeToString(sum[:8]), name}, " ")
+}
+
+func (h *Handler) resolveZone(c *cli.Context, cache ZoneCache, key, name string) (string, error) {
+	id, err := h.Client.ZoneID(c.Context, name)
+	if err != nil {
+		return "", err
+	}
+	cache.Set(key, id)
+	cache.Save()
+	return id, nil
+}
+
+// inZone sends the requests of a command to its zone. When the zone was
+// taken from the cache and a request answers not found, the zone is resolved
+// again and the requests are sent once more if it moved. requests must not
+// read any input, which cannot be read a second time.
+func (h *Handler) inZone(c *cli.Context, requests func(zoneID string) error) error {
+	zoneID, cached, err := h.zoneID(c)
+	if err != nil {
+		return err
+	}
+	err = requests(zoneID)
+	var apiErr *cloudflare.Error
+	if cached == nil || !errors.As(err, &apiErr) || !apiErr.IsNotFound() {
+		return err
+	}
+
+	cache := OpenZoneCache()
+	delete(cache, cached.Key)
+	id, resolveErr := h.resolveZone(c, cache, cached.Key, cached.Name)
+	if resolveErr != nil {
+		cache.Save()
+		return err
+	}
+	if id == cached.ID {
+		return err
+	}
+	return requests(id)
+}
+
+func (h *Handler) ListZones(c *cli.Context) error {
+	if err := h.shouldReady(); err != nil {
+		return err
+	}
+
+	output, err := NewOutput(c)
+	if err != nil {
+		return err
+	}
+
+	zones, info, err := h.Client.ListZones(c.Context, map[string]string{
+		"name":       c.String("name"),
+		"status":     c.String("status"),
+		"account.id": c.String("account-id"),
+		"page":       strconv.FormatUint(uint64(c.Uint("page")), 10),
+		"per_page":   strconv.FormatUint(uint64(c.Uint("per-page")), 10),
+	})
+	if err != nil {
+		return err
+	}
+
+	cache := OpenZoneCache()
+	for _, zone := range zones {
+		cache.Set(h.zoneCacheKey(zone.Name), zone.ID)
+	}
+	cache.Save()
+
+	return output.List(zones, info, "id", "name", "status", "type", "account.name")
+}
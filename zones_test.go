@@ -0,0 +1,176 @@
+package main
+
+import (
+	"cf-cli/cloudflare"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// zoneStandIn serves the zones of zones, keyed by name, and records of the
+// zones whose identifier is in zones.
+func zoneStandIn(t *testing.T, zones map[string][]string) *standIn {
+	return newStandIn(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/client/v4/zones" {
+			result := []cloudflare.Zone{}
+			for _, id := range zones[r.URL.Query().Get("name")] {
+				result = append(result, cloudflare.Zone{ID: id, Name: r.URL.Query().Get("name")})
+			}
+			writeResult(w, result, nil)
+			return
+		}
+		for _, ids := range zones {
+			for _, id := range ids {
+				if strings.HasPrefix(r.URL.Path, "/client/v4/zones/"+id+"/") {
+					writeResult(w, map[string]any{"id": "record"}, nil)
+					return
+				}
+			}
+		}
+		writeError(w, http.StatusNotFound, 7003, "Could not route to /zones, perhaps your object identifier is invalid?")
+	})
+}
+
+func zoneRequests(s *standIn) (lookups int, paths []string) {
+	for _, r := range s.Requests() {
+		if r.Path == "/client/v4/zones" {
+			lookups++
+		} else {
+			paths = append(paths, r.Path)
+		}
+	}
+	return lookups, paths
+}
+
+func TestZoneResolvedOnceAndCached(t *testing.T) {
+	isolate(t)
+	s := zoneStandIn(t, map[string][]string{"example.com": {"z1"}})
+	args := []string{"details", "--zone", "Example.com.", "--record-id", "record"}
+
+	for i := 0; i < 2; i++ {
+		if _, err := s.run(t, nil, args...); err != nil {
+			t.Fatalf("run %d failed: %s", i, err)
+		}
+	}
+	lookups, paths := zoneRequests(s)
+	if lookups != 1 {
+		t.Errorf("zone looked up %d times, want once", lookups)
+	}
+	for _, path := range paths {
+		if path != "/client/v4/zones/z1/dns_records/record" {
+			t.Errorf("record requested at %s", path)
+		}
+	}
+
+	// Other credentials may see another zone of the same name.
+	if _, err := s.run(t, nil, append([]string{"--api-token", "other"}, args...)...); err != nil {
+		t.Fatalf("run with other credentials failed: %s", err)
+	}
+	if lookups, _ = zoneRequests(s); lookups != 2 {
+		t.Errorf("zone looked up %d times with other credentials, want again", lookups)
+	}
+}
+
+func TestZoneCacheEntryExpires(t *testing.T) {
+	isolate(t)
+	s := zoneStandIn(t, map[string][]string{"example.com": {"z1"}})
+	handler := &Handler{Configuration: &SecurityConfiguration{APIToken: "token"}}
+	if _, err := s.run(t, handler, "details", "--zone", "example.com", "--record-id", "record"); err != nil {
+		t.Fatal(err)
+	}
+
+	cache := OpenZoneCache()
+	key := handler.zoneCacheKey("example.com")
+	cache[key] = ZoneCacheEntry{ID: "z1", Resolved: time.Now().Add(-2 * zoneCacheTTL)}
+	if err := cache.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := OpenZoneCache().Get(key); ok {
+		t.Errorf("expired entry is still cached")
+	}
+}
+
+func TestStaleCachedZoneIsResolvedAgain(t *testing.T) {
+	isolate(t)
+	zones := map[string][]string{"example.com": {"old"}}
+	s := zoneStandIn(t, zones)
+	args := []string{"details", "--zone", "example.com", "--record-id", "record"}
+	if _, err := s.run(t, nil, args...); err != nil {
+		t.Fatal(err)
+	}
+
+	zones["example.com"] = []string{"new"}
+	if _, err := s.run(t, nil, args...); err != nil {
+		t.Fatalf("run with a stale cached zone failed: %s", err)
+	}
+	lookups, paths := zoneRequests(s)
+	want := []string{"/client/v4/zones/old/dns_records/record", "/client/v4/zones/old/dns_records/record", "/client/v4/zones/new/dns_records/record"}
+	if lookups != 2 || strings.Join(paths, " ") != strings.Join(want, " ") {
+		t.Errorf("lookups = %d, paths = %v, want 2 and %v", lookups, paths, want)
+	}
+
+	// The record itself missing from a zone that still resolves the same is
+	// not retried.
+	if _, err := s.run(t, nil, "details", "--zone", "example.com", "--record-id", "record", "--zone-id", "gone"); ExitCode(err) != ExitNotFound {
+		t.Errorf("missing zone id exit code = %d, want %d", ExitCode(err), ExitNotFound)
+	}
+}
+
+func TestZoneResolutionErrors(t *testing.T) {
+	isolate(t)
+	s := zoneStandIn(t, map[string][]string{"twice.com": {"z1", "z2"}})
+
+	_, err := s.run(t, nil, "details", "--zone", "missing.com", "--record-id", "record")
+	if ExitCode(err) != ExitNotFound {
+		t.Errorf("zero matches: err = %v, exit code %d, want %d", err, ExitCode(err), ExitNotFound)
+	}
+	_, err = s.run(t, nil, "details", "--zone", "twice.com", "--record-id", "record")
+	if err == nil || !strings.Contains(err.Error(), "matches 2 zones") {
+		t.Errorf("ambiguous matches: err = %v", err)
+	}
+	if _, paths := zoneRequests(s); len(paths) != 0 {
+		t.Errorf("records requested without a zone: %v", paths)
+	}
+	if len(OpenZoneCache()) != 0 {
+		t.Errorf("failed resolutions were cached: %v", OpenZoneCache())
+	}
+}
+
+func TestStaleCachedZoneResendsInput(t *testing.T) {
+	tests := []struct {
+		args  []string
+		input string
+	}{
+		{[]string{"create", "a", "--zone", "example.com", "--from-file", "-"}, `{"name":"www.example.com","content":"192.0.2.1"}`},
+		{[]string{"api", "POST", "/zones/{zone_id}/dns_records", "--zone", "example.com", "--input", "-"}, `{"type":"A","name":"www.example.com","content":"192.0.2.1"}`},
+	}
+	for _, test := range tests {
+		isolate(t)
+		zones := map[string][]string{"example.com": {"old"}}
+		s := zoneStandIn(t, zones)
+		if _, err := s.run(t, nil, "details", "--zone", "example.com", "--record-id", "record"); err != nil {
+			t.Fatal(err)
+		}
+
+		zones["example.com"] = []string{"new"}
+		stdinFrom(t, test.input)
+		if _, err := s.run(t, nil, test.args...); err != nil {
+			t.Fatalf("%v with a stale cached zone failed: %s", test.args, err)
+		}
+		var posted []recordedRequest
+		for _, r := range s.Requests() {
+			if r.Method == "POST" {
+				posted = append(posted, r)
+			}
+		}
+		if len(posted) != 2 || posted[0].Path != "/client/v4/zones/old/dns_records" || posted[1].Path != "/client/v4/zones/new/dns_records" {
+			t.Fatalf("%v posted %v", test.args, posted)
+		}
+		for _, r := range posted {
+			if r.Body["content"] != "192.0.2.1" || r.Body["name"] != "www.example.com" {
+				t.Errorf("%v posted %s to %s", test.args, r.Raw, r.Path)
+			}
+		}
+	}
+}